@@ -0,0 +1,71 @@
+package main
+
+import (
+	"backend/core"
+	"backend/version"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// runCommand runs a command line subcommand instead of the HTTP server.
+func runCommand(db *sqlx.DB, args []string) error {
+	switch args[0] {
+	case "import":
+		return runImport(db, args[1:])
+	}
+	return fmt.Errorf("unknown command %q, available commands: import", args[0])
+}
+
+// runImport imports a migration directory archive into a project:
+//
+//	api-service import -project 3 -user 1 [-format flyway] [-baseline] migrations.zip
+func runImport(db *sqlx.DB, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	projectID := flags.Int("project", 0, "project id to import into")
+	userID := flags.Int("user", 0, "user id recorded as author of the versions")
+	format := flags.String("format", "", "flyway, golang-migrate or liquibase, detected when empty")
+	baseline := flags.Bool("baseline", false, "mark migrations applied that the target's history lists")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *projectID == 0 || *userID == 0 || flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("-project, -user and one archive path are required")
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, version.MaxArchiveSize+1))
+	if err != nil {
+		return err
+	}
+	if len(data) > version.MaxArchiveSize {
+		return errors.New("archive is too large")
+	}
+
+	conn, err := core.NewConnector(db, *projectID)
+	if err != nil {
+		return err
+	}
+
+	response, err := version.NewMigrator(db, conn, *projectID, *userID).Import(version.ImportRequest{
+		ProjectID: *projectID,
+		Format:    *format,
+		Baseline:  *baseline,
+	}, data)
+	if response != nil {
+		output, _ := json.MarshalIndent(response, "", "  ")
+		fmt.Println(string(output))
+	}
+	return err
+}
@@ -1,12 +1,16 @@
 package common
 
+import "time"
+
 type Config struct {
-	JwtSecretKey []byte
-	PsqlHost     string
-	PsqlPort     int
-	PsqlUser     string
-	PsqlPassword string
-	PsqlDatabase string
+	JwtSecretKey     []byte
+	PsqlHost         string
+	PsqlPort         int
+	PsqlUser         string
+	PsqlPassword     string
+	PsqlDatabase     string
+	SnapshotInterval time.Duration
+	DriftInterval    time.Duration
 }
 
 type DatabaseQueryResult struct {
@@ -0,0 +1,189 @@
+package connectors
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	DialectPostgres = "psql"
+	DialectMySQL    = "mysql"
+	DialectMSSQL    = "mssql"
+)
+
+const (
+	ObjectTypeTable     = "table"
+	ObjectTypeView      = "view"
+	ObjectTypeIndex     = "index"
+	ObjectTypeFunction  = "function"
+	ObjectTypeProcedure = "procedure"
+	ObjectTypeSequence  = "sequence"
+	ObjectTypeTrigger   = "trigger"
+)
+
+var (
+	ErrObjectNotFound        = errors.New("object not found")
+	ErrUnsupportedObjectType = errors.New("object type is not supported by this database")
+)
+
+// tableDialect holds the dialect specific pieces needed to render table and
+// index definitions from introspected metadata.
+type tableDialect struct {
+	quote         func(string) string
+	identity      string
+	formatDefault func(string) string
+	constraint    func(ConstraintStructureResponse) string
+	indexes       func(TableStructureResponse) []string
+}
+
+func qualifiedName(quote func(string) string, schemaName string, name string) string {
+	if schemaName == "" {
+		return quote(name)
+	}
+	return quote(schemaName) + "." + quote(name)
+}
+
+func quoteList(quote func(string) string, names []string) string {
+	quoted := make([]string, 0, len(names))
+	for _, name := range names {
+		quoted = append(quoted, quote(name))
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func columnDefinition(d tableDialect, col ColumnStructureResponse) string {
+	def := fmt.Sprintf("%s %s", d.quote(col.ColumnName), columnType(col))
+	if col.Identity {
+		def += " " + d.identity
+	}
+	if !col.Nullable {
+		def += " NOT NULL"
+	}
+	if col.DefaultValue != nil && !col.Identity {
+		def += " DEFAULT " + d.formatDefault(*col.DefaultValue)
+	}
+
+	return def
+}
+
+// buildCreateTable renders the CREATE TABLE statement for an introspected
+// table followed by the statements for its secondary indexes.
+func buildCreateTable(d tableDialect, table TableStructureResponse) string {
+	lines := make([]string, 0, len(table.Columns)+len(table.Constraints))
+	for _, col := range table.Columns {
+		lines = append(lines, columnDefinition(d, col))
+	}
+	for _, constraint := range table.Constraints {
+		lines = append(lines, d.constraint(constraint))
+	}
+
+	statements := []string{fmt.Sprintf(
+		"CREATE TABLE %s (\n  %s\n);",
+		qualifiedName(d.quote, table.SchemaName, table.TableName),
+		strings.Join(lines, ",\n  "),
+	)}
+	statements = append(statements, d.indexes(table)...)
+
+	return strings.Join(statements, "\n\n")
+}
+
+// namedConstraint renders a constraint from its column lists. It is used by
+// dialects that do not expose a ready made constraint definition.
+func namedConstraint(quote func(string) string, c ConstraintStructureResponse) string {
+	prefix := fmt.Sprintf("CONSTRAINT %s ", quote(c.ConstraintName))
+
+	switch c.ConstraintType {
+	case "PRIMARY KEY", "UNIQUE":
+		return prefix + fmt.Sprintf("%s (%s)", c.ConstraintType, quoteList(quote, c.Columns))
+	case "FOREIGN KEY":
+		definition := fmt.Sprintf(
+			"FOREIGN KEY (%s) REFERENCES %s (%s)",
+			quoteList(quote, c.Columns),
+			qualifiedName(quote, c.ReferencedSchema, c.ReferencedTable),
+			quoteList(quote, c.ReferencedColumns),
+		)
+		if c.OnDelete != "" {
+			definition += " ON DELETE " + c.OnDelete
+		}
+		if c.OnUpdate != "" {
+			definition += " ON UPDATE " + c.OnUpdate
+		}
+		return prefix + definition
+	default:
+		definition := strings.TrimSpace(c.Definition)
+		if !strings.HasPrefix(definition, "(") {
+			definition = "(" + definition + ")"
+		}
+		return prefix + "CHECK " + definition
+	}
+}
+
+// secondaryIndexes returns the indexes of a table that are not created
+// implicitly by its primary key or unique constraints.
+func secondaryIndexes(table TableStructureResponse) []IndexStructureResponse {
+	constraintNames := make(map[string]bool)
+	for _, c := range table.Constraints {
+		constraintNames[c.ConstraintName] = true
+	}
+
+	var indexes []IndexStructureResponse
+	for _, index := range table.Indexes {
+		if index.Primary || constraintNames[index.IndexName] {
+			continue
+		}
+		indexes = append(indexes, index)
+	}
+	return indexes
+}
+
+func createIndexStatement(quote func(string) string, schemaName string, tableName string, index IndexStructureResponse) string {
+	if index.Primary {
+		return fmt.Sprintf(
+			"ALTER TABLE %s ADD PRIMARY KEY (%s);",
+			qualifiedName(quote, schemaName, tableName),
+			quoteList(quote, index.Columns),
+		)
+	}
+
+	unique := ""
+	if index.Unique {
+		unique = "UNIQUE "
+	}
+
+	statement := fmt.Sprintf(
+		"CREATE %sINDEX %s ON %s (%s)",
+		unique,
+		quote(index.IndexName),
+		qualifiedName(quote, schemaName, tableName),
+		quoteList(quote, index.Columns),
+	)
+	if index.Predicate != "" {
+		statement += " WHERE " + index.Predicate
+	}
+
+	return statement + ";"
+}
+
+func findIndex(table TableStructureResponse, indexName string) (IndexStructureResponse, bool) {
+	for _, index := range table.Indexes {
+		if index.IndexName == indexName {
+			return index, true
+		}
+	}
+	return IndexStructureResponse{}, false
+}
+
+func columnType(col ColumnStructureResponse) string {
+	if col.ColumnType != "" {
+		return col.ColumnType
+	}
+	return col.DataType
+}
+
+func sameDefault(a ColumnStructureResponse, b ColumnStructureResponse) bool {
+	if a.DefaultValue == nil || b.DefaultValue == nil {
+		return a.DefaultValue == nil && b.DefaultValue == nil
+	}
+	return *a.DefaultValue == *b.DefaultValue
+}
@@ -0,0 +1,261 @@
+package connectors
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// HistoryTableName is the table ChronoDB maintains inside every target
+// database so the applied state travels with the database itself.
+const HistoryTableName = "chronodb_schema_history"
+
+// WithoutHistoryTable drops the history table from a structure, it is created
+// by the migrator and not part of the project schema.
+func WithoutHistoryTable(structure *DatabaseStructureResponse) *DatabaseStructureResponse {
+	result := &DatabaseStructureResponse{Schemas: make([]SchemaStructureResponse, 0, len(structure.Schemas))}
+	for _, schema := range structure.Schemas {
+		tables := make([]TableStructureResponse, 0, len(schema.Tables))
+		for _, table := range schema.Tables {
+			if table.TableName != HistoryTableName {
+				tables = append(tables, table)
+			}
+		}
+		result.Schemas = append(result.Schemas, SchemaStructureResponse{SchemaName: schema.SchemaName, Tables: tables})
+	}
+	return result
+}
+
+const (
+	HistoryOperationApply    = "apply"
+	HistoryOperationRollback = "rollback"
+)
+
+type HistoryEntry struct {
+	VersionID  int       `json:"versionId"`
+	Version    string    `json:"version"`
+	Operation  string    `json:"operation"`
+	Checksum   string    `json:"checksum"`
+	AppliedBy  int       `json:"appliedBy"`
+	AppliedAt  time.Time `json:"appliedAt"`
+	DurationMs int64     `json:"durationMs"`
+	Success    bool      `json:"success"`
+}
+
+// Session is an open connection or transaction on a target database.
+type Session interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// SchemaHistory reads and writes the history table of a target database on an
+// already opened session, so entries can be written in the migration's
+// transaction.
+type SchemaHistory interface {
+	// EnsureHistoryTable creates the history table if it is missing and
+	// reports whether it had to be created.
+	EnsureHistoryTable(ctx context.Context, conn Session) (bool, error)
+	HasHistoryTable(ctx context.Context, conn Session) (bool, error)
+	ReadHistory(ctx context.Context, conn Session) ([]HistoryEntry, error)
+	RecordHistory(ctx context.Context, conn Session, entry HistoryEntry) error
+}
+
+type historyDialect struct {
+	exists string
+	create string
+	insert string
+	// table is the name the history table is read from, qualified where the
+	// create statement qualifies it.
+	table string
+}
+
+var postgresHistory = historyDialect{
+	exists: `SELECT to_regclass('` + HistoryTableName + `') IS NOT NULL`,
+	create: `
+		CREATE TABLE IF NOT EXISTS ` + HistoryTableName + ` (
+			id          SERIAL PRIMARY KEY,
+			version_id  INT         NOT NULL,
+			version     VARCHAR(50) NOT NULL,
+			operation   VARCHAR(16) NOT NULL,
+			checksum    VARCHAR(64) NOT NULL,
+			applied_by  INT,
+			applied_at  TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			duration_ms BIGINT      NOT NULL,
+			success     BOOLEAN     NOT NULL
+		)`,
+	insert: `
+		INSERT INTO ` + HistoryTableName + `
+			(version_id, version, operation, checksum, applied_by, applied_at, duration_ms, success)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+	table: HistoryTableName,
+}
+
+var mysqlHistory = historyDialect{
+	exists: `
+		SELECT COUNT(*) > 0
+		FROM INFORMATION_SCHEMA.TABLES
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = '` + HistoryTableName + `'`,
+	create: `
+		CREATE TABLE IF NOT EXISTS ` + HistoryTableName + ` (
+			id          INT AUTO_INCREMENT PRIMARY KEY,
+			version_id  INT         NOT NULL,
+			version     VARCHAR(50) NOT NULL,
+			operation   VARCHAR(16) NOT NULL,
+			checksum    VARCHAR(64) NOT NULL,
+			applied_by  INT,
+			applied_at  DATETIME    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			duration_ms BIGINT      NOT NULL,
+			success     BOOLEAN     NOT NULL
+		)`,
+	insert: `
+		INSERT INTO ` + HistoryTableName + `
+			(version_id, version, operation, checksum, applied_by, applied_at, duration_ms, success)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+	table: HistoryTableName,
+}
+
+// mssqlHistoryTable is qualified, a login's default schema need not be dbo.
+const mssqlHistoryTable = "dbo." + HistoryTableName
+
+var mssqlHistory = historyDialect{
+	exists: `SELECT CASE WHEN OBJECT_ID(N'` + mssqlHistoryTable + `', N'U') IS NULL THEN 0 ELSE 1 END`,
+	create: `
+		IF OBJECT_ID(N'` + mssqlHistoryTable + `', N'U') IS NULL
+		CREATE TABLE ` + mssqlHistoryTable + ` (
+			id          INT IDENTITY(1,1) PRIMARY KEY,
+			version_id  INT          NOT NULL,
+			version     NVARCHAR(50) NOT NULL,
+			operation   NVARCHAR(16) NOT NULL,
+			checksum    NVARCHAR(64) NOT NULL,
+			applied_by  INT,
+			applied_at  DATETIME2    NOT NULL DEFAULT SYSUTCDATETIME(),
+			duration_ms BIGINT       NOT NULL,
+			success     BIT          NOT NULL
+		)`,
+	insert: `
+		INSERT INTO ` + mssqlHistoryTable + `
+			(version_id, version, operation, checksum, applied_by, applied_at, duration_ms, success)
+		VALUES (@p1, @p2, @p3, @p4, @p5, @p6, @p7, @p8)`,
+	table: mssqlHistoryTable,
+}
+
+func (h historyDialect) has(ctx context.Context, conn Session) (bool, error) {
+	var exists bool
+	err := conn.QueryRowContext(ctx, h.exists).Scan(&exists)
+	return exists, err
+}
+
+func (h historyDialect) ensure(ctx context.Context, conn Session) (bool, error) {
+	exists, err := h.has(ctx, conn)
+	if err != nil || exists {
+		return false, err
+	}
+
+	if _, err := conn.ExecContext(ctx, h.create); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (h historyDialect) read(ctx context.Context, conn Session) ([]HistoryEntry, error) {
+	rows, err := conn.QueryContext(ctx, `
+		SELECT version_id, version, operation, checksum, COALESCE(applied_by, 0), applied_at, duration_ms, success
+		FROM `+h.table+`
+		ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []HistoryEntry{}
+	for rows.Next() {
+		var entry HistoryEntry
+		if err := rows.Scan(
+			&entry.VersionID,
+			&entry.Version,
+			&entry.Operation,
+			&entry.Checksum,
+			&entry.AppliedBy,
+			&entry.AppliedAt,
+			&entry.DurationMs,
+			&entry.Success,
+		); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+func (h historyDialect) record(ctx context.Context, conn Session, entry HistoryEntry) error {
+	if entry.AppliedAt.IsZero() {
+		entry.AppliedAt = time.Now().UTC()
+	}
+
+	var appliedBy any
+	if entry.AppliedBy != 0 {
+		appliedBy = entry.AppliedBy
+	}
+
+	_, err := conn.ExecContext(ctx, h.insert,
+		entry.VersionID,
+		entry.Version,
+		entry.Operation,
+		entry.Checksum,
+		appliedBy,
+		entry.AppliedAt,
+		entry.DurationMs,
+		entry.Success,
+	)
+	return err
+}
+
+func (p PostgresConnector) EnsureHistoryTable(ctx context.Context, conn Session) (bool, error) {
+	return postgresHistory.ensure(ctx, conn)
+}
+
+func (p PostgresConnector) HasHistoryTable(ctx context.Context, conn Session) (bool, error) {
+	return postgresHistory.has(ctx, conn)
+}
+
+func (p PostgresConnector) ReadHistory(ctx context.Context, conn Session) ([]HistoryEntry, error) {
+	return postgresHistory.read(ctx, conn)
+}
+
+func (p PostgresConnector) RecordHistory(ctx context.Context, conn Session, entry HistoryEntry) error {
+	return postgresHistory.record(ctx, conn, entry)
+}
+
+func (m MySQLConnector) EnsureHistoryTable(ctx context.Context, conn Session) (bool, error) {
+	return mysqlHistory.ensure(ctx, conn)
+}
+
+func (m MySQLConnector) HasHistoryTable(ctx context.Context, conn Session) (bool, error) {
+	return mysqlHistory.has(ctx, conn)
+}
+
+func (m MySQLConnector) ReadHistory(ctx context.Context, conn Session) ([]HistoryEntry, error) {
+	return mysqlHistory.read(ctx, conn)
+}
+
+func (m MySQLConnector) RecordHistory(ctx context.Context, conn Session, entry HistoryEntry) error {
+	return mysqlHistory.record(ctx, conn, entry)
+}
+
+func (m MSSQLConnector) EnsureHistoryTable(ctx context.Context, conn Session) (bool, error) {
+	return mssqlHistory.ensure(ctx, conn)
+}
+
+func (m MSSQLConnector) HasHistoryTable(ctx context.Context, conn Session) (bool, error) {
+	return mssqlHistory.has(ctx, conn)
+}
+
+func (m MSSQLConnector) ReadHistory(ctx context.Context, conn Session) ([]HistoryEntry, error) {
+	return mssqlHistory.read(ctx, conn)
+}
+
+func (m MSSQLConnector) RecordHistory(ctx context.Context, conn Session, entry HistoryEntry) error {
+	return mssqlHistory.record(ctx, conn, entry)
+}
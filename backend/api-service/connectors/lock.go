@@ -0,0 +1,99 @@
+package connectors
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// MigrationLockName identifies the engine-native lock taken on a target
+// database while ChronoDB changes it.
+const MigrationLockName = "chronodb_migration"
+
+var ErrLockTimeout = errors.New("timed out waiting for migration lock")
+
+// MigrationLocker takes a session level lock on the target database, so two
+// ChronoDB instances never migrate the same database at once, even when they
+// use different metadata databases.
+type MigrationLocker interface {
+	AcquireMigrationLock(ctx context.Context, conn *sql.Conn, timeout time.Duration) error
+	ReleaseMigrationLock(ctx context.Context, conn *sql.Conn) error
+}
+
+const lockPollInterval = 500 * time.Millisecond
+
+func (p PostgresConnector) AcquireMigrationLock(ctx context.Context, conn *sql.Conn, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		var locked bool
+		err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, MigrationLockName).Scan(&locked)
+		if err != nil {
+			return err
+		}
+		if locked {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return ErrLockTimeout
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+func (p PostgresConnector) ReleaseMigrationLock(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock(hashtext($1))`, MigrationLockName)
+	return err
+}
+
+// mysqlLockName scopes the lock to the current database, MySQL lock names are
+// server-wide. The database name is hashed to stay within the 64 characters a
+// lock name may have.
+const mysqlLockName = `CONCAT(?, ':', MD5(COALESCE(DATABASE(), '')))`
+
+func (m MySQLConnector) AcquireMigrationLock(ctx context.Context, conn *sql.Conn, timeout time.Duration) error {
+	var locked sql.NullInt64
+	err := conn.QueryRowContext(ctx, `SELECT GET_LOCK(`+mysqlLockName+`, ?)`, MigrationLockName, int(timeout.Seconds())).Scan(&locked)
+	if err != nil {
+		return err
+	}
+	if !locked.Valid || locked.Int64 != 1 {
+		return ErrLockTimeout
+	}
+	return nil
+}
+
+func (m MySQLConnector) ReleaseMigrationLock(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `SELECT RELEASE_LOCK(`+mysqlLockName+`)`, MigrationLockName)
+	return err
+}
+
+func (m MSSQLConnector) AcquireMigrationLock(ctx context.Context, conn *sql.Conn, timeout time.Duration) error {
+	var result int
+	err := conn.QueryRowContext(ctx, `
+		DECLARE @result int;
+		EXEC @result = sp_getapplock
+			@Resource = @p1,
+			@LockMode = 'Exclusive',
+			@LockOwner = 'Session',
+			@LockTimeout = @p2;
+		SELECT @result;`, MigrationLockName, timeout.Milliseconds()).Scan(&result)
+	if err != nil {
+		return err
+	}
+	// sp_getapplock returns 0 or 1 when granted and a negative value otherwise.
+	if result < 0 {
+		return ErrLockTimeout
+	}
+	return nil
+}
+
+func (m MSSQLConnector) ReleaseMigrationLock(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `EXEC sp_releaseapplock @Resource = @p1, @LockOwner = 'Session'`, MigrationLockName)
+	return err
+}
@@ -7,11 +7,49 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
+// SchemaChangeGenerator renders DDL statements in the dialect of a database
+// engine. It does not need a connection to the target database.
+type SchemaChangeGenerator interface {
+	TypeMapper
+	Dialect() string
+	QuoteIdentifier(name string) string
+	CreateSchemaStatement(schemaName string) string
+	DropSchemaStatement(schemaName string) string
+	CreateTableStatement(table TableStructureResponse) string
+	DropTableStatement(schemaName string, tableName string) string
+	RenameTableStatement(schemaName string, tableName string, newName string) string
+	AddColumnStatement(schemaName string, tableName string, col ColumnStructureResponse) string
+	DropColumnStatement(schemaName string, tableName string, columnName string) string
+	RenameColumnStatement(schemaName string, tableName string, columnName string, newName string) string
+	AlterColumnStatements(schemaName string, tableName string, from ColumnStructureResponse, to ColumnStructureResponse) ([]string, error)
+	CreateIndexStatement(schemaName string, tableName string, index IndexStructureResponse) string
+	DropIndexStatement(schemaName string, tableName string, index IndexStructureResponse) string
+	AddConstraintStatement(schemaName string, tableName string, constraint ConstraintStructureResponse) string
+	DropConstraintStatement(schemaName string, tableName string, constraint ConstraintStructureResponse) string
+	JoinStatements(statements []string) string
+	SplitStatements(script string) []string
+	// SupportsTransaction reports whether a statement can run inside a
+	// transaction and be rolled back together with it.
+	SupportsTransaction(statement string) bool
+}
+
 type DBConnector interface {
+	SchemaChangeGenerator
+	SchemaHistory
+	MigrationLocker
 	Connect(connectionString string) (*sql.DB, error)
+	Open(projectID int) (*sql.DB, error)
 	ExecuteQuery(projectID int, query string) (*common.DatabaseQueryResult, error)
 	GetVersionQuery() string
 	GetDatabaseStructure(projectID int) (*DatabaseStructureResponse, error)
+	IntrospectDatabase(projectID int) (*DatabaseStructureResponse, error)
+	// ListObjects lists the views, routines, sequences and triggers that
+	// IntrospectDatabase leaves out.
+	ListObjects(projectID int) ([]SchemaObject, error)
+	ListSchemas(projectID int) ([]SchemaSummaryResponse, error)
+	ListTables(projectID int, req TableListRequest) (*TableListResponse, error)
+	GetTableDetail(projectID int, schemaName string, tableName string) (*TableStructureResponse, error)
+	GetObjectDDL(projectID int, req ObjectDDLRequest) (*ObjectDDLResponse, error)
 	BuildConnectionString(projectID int, metaDB *sqlx.DB) (string, error)
 }
 
@@ -41,11 +79,88 @@ type SchemaStructureResponse struct {
 }
 
 type TableStructureResponse struct {
-	TableName string                    `json:"tableName"`
-	Columns   []ColumnStructureResponse `json:"columns"`
+	SchemaName  string                        `json:"schemaName,omitempty"`
+	TableName   string                        `json:"tableName"`
+	Columns     []ColumnStructureResponse     `json:"columns"`
+	Indexes     []IndexStructureResponse      `json:"indexes,omitempty"`
+	Constraints []ConstraintStructureResponse `json:"constraints,omitempty"`
 }
 
 type ColumnStructureResponse struct {
-	ColumnName string `json:"columnName"`
-	DataType   string `json:"dataType"`
+	ColumnName      string  `json:"columnName"`
+	DataType        string  `json:"dataType"`
+	ColumnType      string  `json:"columnType,omitempty"`
+	Nullable        bool    `json:"nullable,omitempty"`
+	DefaultValue    *string `json:"defaultValue,omitempty"`
+	OrdinalPosition int     `json:"ordinalPosition,omitempty"`
+	Identity        bool    `json:"identity,omitempty"`
+}
+
+type IndexStructureResponse struct {
+	IndexName  string   `json:"indexName"`
+	Columns    []string `json:"columns"`
+	Unique     bool     `json:"unique"`
+	Primary    bool     `json:"primary"`
+	Predicate  string   `json:"predicate,omitempty"`
+	Definition string   `json:"definition,omitempty"`
+}
+
+type ConstraintStructureResponse struct {
+	ConstraintName    string   `json:"constraintName"`
+	ConstraintType    string   `json:"constraintType"`
+	Columns           []string `json:"columns,omitempty"`
+	ReferencedSchema  string   `json:"referencedSchema,omitempty"`
+	ReferencedTable   string   `json:"referencedTable,omitempty"`
+	ReferencedColumns []string `json:"referencedColumns,omitempty"`
+	OnDelete          string   `json:"onDelete,omitempty"`
+	OnUpdate          string   `json:"onUpdate,omitempty"`
+	Definition        string   `json:"definition,omitempty"`
+}
+
+// SchemaObject is an object of a target database other than a table.
+type SchemaObject struct {
+	SchemaName string `json:"schemaName"`
+	ObjectName string `json:"objectName"`
+	ObjectType string `json:"objectType"`
+}
+
+type SchemaSummaryResponse struct {
+	SchemaName string `json:"schemaName"`
+	TableCount int    `json:"tableCount"`
+}
+
+type TableListRequest struct {
+	SchemaName string
+	Search     string
+	Page       int
+	PageSize   int
+}
+
+type TableSummaryResponse struct {
+	TableName      string `json:"tableName"`
+	EstimatedRows  int64  `json:"estimatedRows"`
+	TotalSizeBytes int64  `json:"totalSizeBytes"`
+}
+
+type TableListResponse struct {
+	SchemaName string                 `json:"schemaName"`
+	Tables     []TableSummaryResponse `json:"tables"`
+	Total      int                    `json:"total"`
+	Page       int                    `json:"page"`
+	PageSize   int                    `json:"pageSize"`
+}
+
+type ObjectDDLRequest struct {
+	ObjectType string
+	SchemaName string
+	ObjectName string
+	TableName  string
+}
+
+type ObjectDDLResponse struct {
+	Dialect    string `json:"dialect"`
+	ObjectType string `json:"objectType"`
+	SchemaName string `json:"schemaName"`
+	ObjectName string `json:"objectName"`
+	Statement  string `json:"statement"`
 }
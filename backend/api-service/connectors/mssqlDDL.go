@@ -0,0 +1,255 @@
+package connectors
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+var mssqlTableDialect = tableDialect{
+	quote:         quoteMSSQL,
+	identity:      "IDENTITY(1,1)",
+	formatDefault: func(value string) string { return value },
+	constraint: func(c ConstraintStructureResponse) string {
+		return namedConstraint(quoteMSSQL, c)
+	},
+	indexes: func(table TableStructureResponse) []string {
+		var statements []string
+		for _, index := range secondaryIndexes(table) {
+			statements = append(statements, createIndexStatement(quoteMSSQL, table.SchemaName, table.TableName, index))
+		}
+		return statements
+	},
+}
+
+func quoteMSSQL(name string) string {
+	return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+}
+
+func (m MSSQLConnector) Dialect() string {
+	return DialectMSSQL
+}
+
+func (m MSSQLConnector) QuoteIdentifier(name string) string {
+	return quoteMSSQL(name)
+}
+
+func (m MSSQLConnector) CreateTableStatement(table TableStructureResponse) string {
+	return buildCreateTable(mssqlTableDialect, table)
+}
+
+func (m *MSSQLConnector) GetObjectDDL(projectID int, req ObjectDDLRequest) (*ObjectDDLResponse, error) {
+	db, err := m.Open(projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	name := qualifiedName(quoteMSSQL, req.SchemaName, req.ObjectName)
+
+	var statement string
+	switch req.ObjectType {
+	case ObjectTypeTable:
+		tables, err := loadTables(db, mssqlColumnsQuery, mssqlIndexesQuery, mssqlConstraintsQuery, req.SchemaName, req.ObjectName)
+		if err != nil {
+			return nil, err
+		}
+		if len(tables) == 0 {
+			return nil, ErrObjectNotFound
+		}
+		statement = m.CreateTableStatement(tables[0])
+
+	case ObjectTypeView, ObjectTypeFunction, ObjectTypeProcedure:
+		types := map[string]string{
+			ObjectTypeView:      "'V'",
+			ObjectTypeFunction:  "'FN', 'IF', 'TF', 'FS', 'FT'",
+			ObjectTypeProcedure: "'P', 'PC'",
+		}
+		query := `
+			SELECT OBJECT_DEFINITION(o.object_id)
+			FROM sys.objects o
+			JOIN sys.schemas s ON s.schema_id = o.schema_id
+			WHERE s.name = @p1 AND o.name = @p2 AND o.type IN (` + types[req.ObjectType] + `)
+		`
+		var definition sql.NullString
+		if err := db.QueryRow(query, req.SchemaName, req.ObjectName).Scan(&definition); err != nil {
+			return nil, objectQueryError(err)
+		}
+		if !definition.Valid {
+			return nil, fmt.Errorf("definition of %s is encrypted or not visible to the connected user", name)
+		}
+		statement = strings.TrimSpace(definition.String)
+
+	case ObjectTypeIndex:
+		tables, err := loadTables(db, mssqlColumnsQuery, mssqlIndexesQuery, mssqlConstraintsQuery, req.SchemaName, req.TableName)
+		if err != nil {
+			return nil, err
+		}
+		if len(tables) == 0 {
+			return nil, ErrObjectNotFound
+		}
+		index, ok := findIndex(tables[0], req.ObjectName)
+		if !ok {
+			return nil, ErrObjectNotFound
+		}
+		statement = createIndexStatement(quoteMSSQL, req.SchemaName, req.TableName, index)
+
+	case ObjectTypeSequence:
+		var dataType, start, increment, minValue, maxValue string
+		var cycle bool
+		query := `
+			SELECT
+				TYPE_NAME(seq.user_type_id),
+				CAST(seq.start_value AS varchar(64)),
+				CAST(seq.increment AS varchar(64)),
+				CAST(seq.minimum_value AS varchar(64)),
+				CAST(seq.maximum_value AS varchar(64)),
+				seq.is_cycling
+			FROM sys.sequences seq
+			JOIN sys.schemas s ON s.schema_id = seq.schema_id
+			WHERE s.name = @p1 AND seq.name = @p2
+		`
+		if err := db.QueryRow(query, req.SchemaName, req.ObjectName).Scan(&dataType, &start, &increment, &minValue, &maxValue, &cycle); err != nil {
+			return nil, objectQueryError(err)
+		}
+		statement = fmt.Sprintf(
+			"CREATE SEQUENCE %s AS %s START WITH %s INCREMENT BY %s MINVALUE %s MAXVALUE %s %s;",
+			name, dataType, start, increment, minValue, maxValue, cycleClause(cycle),
+		)
+
+	default:
+		return nil, ErrUnsupportedObjectType
+	}
+
+	return &ObjectDDLResponse{
+		Dialect:    DialectMSSQL,
+		ObjectType: req.ObjectType,
+		SchemaName: req.SchemaName,
+		ObjectName: req.ObjectName,
+		Statement:  statement,
+	}, nil
+}
+
+func (m MSSQLConnector) CreateSchemaStatement(schemaName string) string {
+	return fmt.Sprintf("CREATE SCHEMA %s;", quoteMSSQL(schemaName))
+}
+
+func (m MSSQLConnector) DropSchemaStatement(schemaName string) string {
+	return fmt.Sprintf("DROP SCHEMA %s;", quoteMSSQL(schemaName))
+}
+
+func (m MSSQLConnector) DropTableStatement(schemaName string, tableName string) string {
+	return fmt.Sprintf("DROP TABLE %s;", qualifiedName(quoteMSSQL, schemaName, tableName))
+}
+
+func (m MSSQLConnector) AddColumnStatement(schemaName string, tableName string, col ColumnStructureResponse) string {
+	return fmt.Sprintf(
+		"ALTER TABLE %s ADD %s;",
+		qualifiedName(quoteMSSQL, schemaName, tableName),
+		columnDefinition(mssqlTableDialect, col),
+	)
+}
+
+func (m MSSQLConnector) DropColumnStatement(schemaName string, tableName string, columnName string) string {
+	table := qualifiedName(quoteMSSQL, schemaName, tableName)
+	return mssqlDropDefault(table, columnName) + "\n" + fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", table, quoteMSSQL(columnName))
+}
+
+// RenameTableStatement and RenameColumnStatement use sp_rename, which takes
+// the object as a string and the new name unquoted.
+func (m MSSQLConnector) RenameTableStatement(schemaName string, tableName string, newName string) string {
+	return fmt.Sprintf(
+		"EXEC sp_rename N'%s', N'%s';",
+		mssqlString(qualifiedName(quoteMSSQL, schemaName, tableName)),
+		mssqlString(newName),
+	)
+}
+
+func (m MSSQLConnector) RenameColumnStatement(schemaName string, tableName string, columnName string, newName string) string {
+	return fmt.Sprintf(
+		"EXEC sp_rename N'%s', N'%s', N'COLUMN';",
+		mssqlString(qualifiedName(quoteMSSQL, schemaName, tableName)+"."+quoteMSSQL(columnName)),
+		mssqlString(newName),
+	)
+}
+
+func mssqlString(value string) string {
+	return strings.ReplaceAll(value, "'", "''")
+}
+
+func (m MSSQLConnector) AlterColumnStatements(schemaName string, tableName string, from ColumnStructureResponse, to ColumnStructureResponse) ([]string, error) {
+	if from.Identity != to.Identity {
+		return nil, fmt.Errorf("changing the identity property of %s requires rebuilding the table", quoteMSSQL(to.ColumnName))
+	}
+
+	table := qualifiedName(quoteMSSQL, schemaName, tableName)
+	column := quoteMSSQL(to.ColumnName)
+
+	var statements []string
+	if !strings.EqualFold(columnType(from), columnType(to)) || from.Nullable != to.Nullable {
+		nullability := "NOT NULL"
+		if to.Nullable {
+			nullability = "NULL"
+		}
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s %s;", table, column, columnType(to), nullability))
+	}
+	if !sameDefault(from, to) {
+		statement := mssqlDropDefault(table, to.ColumnName)
+		if to.DefaultValue != nil {
+			statement += "\n" + fmt.Sprintf("ALTER TABLE %s ADD DEFAULT %s FOR %s;", table, *to.DefaultValue, column)
+		}
+		statements = append(statements, statement)
+	}
+
+	return statements, nil
+}
+
+// mssqlDropDefault drops the default constraint of a column. Default
+// constraints carry generated names on SQL Server, so the name is looked up
+// at execution time.
+func mssqlDropDefault(table string, columnName string) string {
+	escapedTable := strings.ReplaceAll(table, "'", "''")
+	escapedColumn := strings.ReplaceAll(columnName, "'", "''")
+
+	return fmt.Sprintf(`DECLARE @default sysname;
+SELECT @default = dc.name
+FROM sys.default_constraints dc
+JOIN sys.columns c ON c.object_id = dc.parent_object_id AND c.column_id = dc.parent_column_id
+WHERE dc.parent_object_id = OBJECT_ID(N'%s') AND c.name = N'%s';
+IF @default IS NOT NULL EXEC(N'ALTER TABLE %s DROP CONSTRAINT ' + QUOTENAME(@default));`,
+		escapedTable, escapedColumn, escapedTable)
+}
+
+func (m MSSQLConnector) CreateIndexStatement(schemaName string, tableName string, index IndexStructureResponse) string {
+	return createIndexStatement(quoteMSSQL, schemaName, tableName, index)
+}
+
+func (m MSSQLConnector) DropIndexStatement(schemaName string, tableName string, index IndexStructureResponse) string {
+	table := qualifiedName(quoteMSSQL, schemaName, tableName)
+	if index.Primary {
+		return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;", table, quoteMSSQL(index.IndexName))
+	}
+	return fmt.Sprintf("DROP INDEX %s ON %s;", quoteMSSQL(index.IndexName), table)
+}
+
+func (m MSSQLConnector) AddConstraintStatement(schemaName string, tableName string, constraint ConstraintStructureResponse) string {
+	return fmt.Sprintf(
+		"ALTER TABLE %s ADD %s;",
+		qualifiedName(quoteMSSQL, schemaName, tableName),
+		mssqlTableDialect.constraint(constraint),
+	)
+}
+
+func (m MSSQLConnector) DropConstraintStatement(schemaName string, tableName string, constraint ConstraintStructureResponse) string {
+	return fmt.Sprintf(
+		"ALTER TABLE %s DROP CONSTRAINT %s;",
+		qualifiedName(quoteMSSQL, schemaName, tableName),
+		quoteMSSQL(constraint.ConstraintName),
+	)
+}
+
+// JoinStatements separates statements with GO so every statement runs in its
+// own batch, as sqlcmd and SSMS would execute the script.
+func (m MSSQLConnector) JoinStatements(statements []string) string {
+	return strings.Join(statements, "\nGO\n")
+}
@@ -179,3 +179,243 @@ func (m MSSQLConnector) GetDatabaseStructure(projectId int) (*DatabaseStructureR
 
 	return parseDatabaseStructure(rows)
 }
+
+const mssqlColumnsQuery = `
+	SELECT
+		c.TABLE_SCHEMA,
+		c.TABLE_NAME,
+		c.COLUMN_NAME,
+		c.DATA_TYPE,
+		CASE
+			WHEN c.CHARACTER_MAXIMUM_LENGTH = -1 THEN c.DATA_TYPE + '(max)'
+			WHEN c.CHARACTER_MAXIMUM_LENGTH IS NOT NULL AND c.DATA_TYPE NOT IN ('text', 'ntext', 'image', 'xml')
+				THEN c.DATA_TYPE + '(' + CAST(c.CHARACTER_MAXIMUM_LENGTH AS varchar(10)) + ')'
+			WHEN c.DATA_TYPE IN ('decimal', 'numeric')
+				THEN c.DATA_TYPE + '(' + CAST(c.NUMERIC_PRECISION AS varchar(10)) + ',' + CAST(c.NUMERIC_SCALE AS varchar(10)) + ')'
+			ELSE c.DATA_TYPE
+		END,
+		c.IS_NULLABLE,
+		c.COLUMN_DEFAULT,
+		c.ORDINAL_POSITION,
+		CAST(COALESCE(COLUMNPROPERTY(OBJECT_ID(QUOTENAME(c.TABLE_SCHEMA) + '.' + QUOTENAME(c.TABLE_NAME)), c.COLUMN_NAME, 'IsIdentity'), 0) AS bit)
+	FROM INFORMATION_SCHEMA.COLUMNS c
+	JOIN INFORMATION_SCHEMA.TABLES t ON t.TABLE_SCHEMA = c.TABLE_SCHEMA AND t.TABLE_NAME = c.TABLE_NAME
+	WHERE t.TABLE_TYPE = 'BASE TABLE'
+	  AND (@p1 = '' OR c.TABLE_SCHEMA = @p1)
+	  AND (@p2 = '' OR c.TABLE_NAME = @p2)
+	ORDER BY c.TABLE_SCHEMA, c.TABLE_NAME, c.ORDINAL_POSITION
+`
+
+const mssqlIndexesQuery = `
+	SELECT
+		s.name,
+		t.name,
+		i.name,
+		i.is_unique,
+		i.is_primary_key,
+		col.name,
+		COALESCE(i.filter_definition, ''),
+		''
+	FROM sys.indexes i
+	JOIN sys.tables t ON t.object_id = i.object_id
+	JOIN sys.schemas s ON s.schema_id = t.schema_id
+	JOIN sys.index_columns ic ON ic.object_id = i.object_id AND ic.index_id = i.index_id AND ic.is_included_column = 0
+	JOIN sys.columns col ON col.object_id = ic.object_id AND col.column_id = ic.column_id
+	WHERE i.type > 0
+	  AND t.is_ms_shipped = 0
+	  AND (@p1 = '' OR s.name = @p1)
+	  AND (@p2 = '' OR t.name = @p2)
+	ORDER BY s.name, t.name, i.name, ic.key_ordinal
+`
+
+const mssqlConstraintsQuery = `
+	SELECT
+		tc.TABLE_SCHEMA,
+		tc.TABLE_NAME,
+		tc.CONSTRAINT_NAME,
+		tc.CONSTRAINT_TYPE,
+		COALESCE(kcu.COLUMN_NAME, ''),
+		COALESCE(rk.TABLE_SCHEMA, ''),
+		COALESCE(rk.TABLE_NAME, ''),
+		COALESCE(rk.COLUMN_NAME, ''),
+		COALESCE(cc.CHECK_CLAUSE, ''),
+		CASE WHEN rc.DELETE_RULE IS NULL OR rc.DELETE_RULE = 'NO ACTION' THEN '' ELSE rc.DELETE_RULE END,
+		CASE WHEN rc.UPDATE_RULE IS NULL OR rc.UPDATE_RULE = 'NO ACTION' THEN '' ELSE rc.UPDATE_RULE END
+	FROM INFORMATION_SCHEMA.TABLE_CONSTRAINTS tc
+	LEFT JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE kcu
+		ON kcu.CONSTRAINT_SCHEMA = tc.CONSTRAINT_SCHEMA AND kcu.CONSTRAINT_NAME = tc.CONSTRAINT_NAME
+	LEFT JOIN INFORMATION_SCHEMA.REFERENTIAL_CONSTRAINTS rc
+		ON rc.CONSTRAINT_SCHEMA = tc.CONSTRAINT_SCHEMA AND rc.CONSTRAINT_NAME = tc.CONSTRAINT_NAME
+	LEFT JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE rk
+		ON rk.CONSTRAINT_SCHEMA = rc.UNIQUE_CONSTRAINT_SCHEMA
+		AND rk.CONSTRAINT_NAME = rc.UNIQUE_CONSTRAINT_NAME
+		AND rk.ORDINAL_POSITION = kcu.ORDINAL_POSITION
+	LEFT JOIN INFORMATION_SCHEMA.CHECK_CONSTRAINTS cc
+		ON cc.CONSTRAINT_SCHEMA = tc.CONSTRAINT_SCHEMA AND cc.CONSTRAINT_NAME = tc.CONSTRAINT_NAME
+	WHERE (@p1 = '' OR tc.TABLE_SCHEMA = @p1)
+	  AND (@p2 = '' OR tc.TABLE_NAME = @p2)
+	ORDER BY tc.TABLE_SCHEMA, tc.TABLE_NAME, tc.CONSTRAINT_NAME, kcu.ORDINAL_POSITION
+`
+
+func (m *MSSQLConnector) Open(projectID int) (*sql.DB, error) {
+	conStr, err := m.BuildConnectionString(projectID, m.MetaDataClient)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Connect(conStr)
+}
+
+func (m *MSSQLConnector) ListSchemas(projectID int) ([]SchemaSummaryResponse, error) {
+	db, err := m.Open(projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	query := `
+		SELECT s.name, COUNT(t.object_id)
+		FROM sys.schemas s
+		LEFT JOIN sys.tables t ON t.schema_id = s.schema_id AND t.is_ms_shipped = 0
+		WHERE s.name NOT IN ('sys', 'INFORMATION_SCHEMA', 'guest') AND s.schema_id < 16384
+		GROUP BY s.name
+		ORDER BY s.name
+	`
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schemas := []SchemaSummaryResponse{}
+	for rows.Next() {
+		var schema SchemaSummaryResponse
+		if err := rows.Scan(&schema.SchemaName, &schema.TableCount); err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, schema)
+	}
+
+	return schemas, rows.Err()
+}
+
+func (m *MSSQLConnector) ListTables(projectID int, req TableListRequest) (*TableListResponse, error) {
+	db, err := m.Open(projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	limit, offset := normalizePaging(&req)
+	pattern := likePattern(req.Search)
+
+	response := &TableListResponse{
+		SchemaName: req.SchemaName,
+		Tables:     []TableSummaryResponse{},
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+	}
+
+	countQuery := `
+		SELECT COUNT(*)
+		FROM sys.tables t
+		JOIN sys.schemas s ON s.schema_id = t.schema_id
+		WHERE t.is_ms_shipped = 0 AND s.name = @p1 AND t.name LIKE @p2 ESCAPE '\'
+	`
+	if err := db.QueryRow(countQuery, req.SchemaName, pattern).Scan(&response.Total); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT
+			t.name,
+			COALESCE(SUM(CASE WHEN ps.index_id IN (0, 1) THEN ps.row_count ELSE 0 END), 0),
+			COALESCE(SUM(ps.reserved_page_count), 0) * 8192
+		FROM sys.tables t
+		JOIN sys.schemas s ON s.schema_id = t.schema_id
+		LEFT JOIN sys.dm_db_partition_stats ps ON ps.object_id = t.object_id
+		WHERE t.is_ms_shipped = 0 AND s.name = @p1 AND t.name LIKE @p2 ESCAPE '\'
+		GROUP BY t.name
+		ORDER BY t.name
+		OFFSET @p3 ROWS FETCH NEXT @p4 ROWS ONLY
+	`
+	rows, err := db.Query(query, req.SchemaName, pattern, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var table TableSummaryResponse
+		if err := rows.Scan(&table.TableName, &table.EstimatedRows, &table.TotalSizeBytes); err != nil {
+			return nil, err
+		}
+		response.Tables = append(response.Tables, table)
+	}
+
+	return response, rows.Err()
+}
+
+func (m *MSSQLConnector) GetTableDetail(projectID int, schemaName string, tableName string) (*TableStructureResponse, error) {
+	db, err := m.Open(projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	tables, err := loadTables(db, mssqlColumnsQuery, mssqlIndexesQuery, mssqlConstraintsQuery, schemaName, tableName)
+	if err != nil {
+		return nil, err
+	}
+	if len(tables) == 0 {
+		return nil, ErrTableNotFound
+	}
+
+	return &tables[0], nil
+}
+
+// IntrospectDatabase loads every user table with its columns, indexes and
+// constraints. Unlike GetDatabaseStructure the result is sorted and complete
+// enough to be stored as a snapshot or compared with another structure.
+func (m *MSSQLConnector) IntrospectDatabase(projectID int) (*DatabaseStructureResponse, error) {
+	db, err := m.Open(projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	tables, err := loadTables(db, mssqlColumnsQuery, mssqlIndexesQuery, mssqlConstraintsQuery, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	return groupTables(tables), nil
+}
+
+const mssqlObjectsQuery = `
+	SELECT s.name, o.name,
+		CASE o.type
+			WHEN 'SO' THEN 'sequence'
+			WHEN 'V' THEN 'view'
+			WHEN 'P' THEN 'procedure'
+			WHEN 'TR' THEN 'trigger'
+			ELSE 'function'
+		END
+	FROM sys.objects o
+	JOIN sys.schemas s ON s.schema_id = o.schema_id
+	WHERE o.type IN ('SO', 'V', 'P', 'FN', 'IF', 'TF', 'TR')
+	  AND o.is_ms_shipped = 0
+	ORDER BY CASE o.type WHEN 'SO' THEN 0 ELSE 1 END, s.name, 3, o.name
+`
+
+// ListObjects lists the views, routines, sequences and triggers of the
+// database, sequences first since tables may depend on them.
+func (m *MSSQLConnector) ListObjects(projectID int) ([]SchemaObject, error) {
+	db, err := m.Open(projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	return loadObjects(db, mssqlObjectsQuery)
+}
@@ -0,0 +1,239 @@
+package connectors
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+var mysqlTableDialect = tableDialect{
+	quote:    quoteMySQL,
+	identity: "AUTO_INCREMENT",
+	// Defaults are SQL already, introspection quotes literals like
+	// DefaultValue does.
+	formatDefault: func(value string) string { return value },
+	constraint: func(c ConstraintStructureResponse) string {
+		if c.ConstraintType == "PRIMARY KEY" {
+			return fmt.Sprintf("PRIMARY KEY (%s)", quoteList(quoteMySQL, c.Columns))
+		}
+		return namedConstraint(quoteMySQL, c)
+	},
+	indexes: func(table TableStructureResponse) []string {
+		var statements []string
+		for _, index := range secondaryIndexes(table) {
+			statements = append(statements, createIndexStatement(quoteMySQL, table.SchemaName, table.TableName, index))
+		}
+		return statements
+	},
+}
+
+func quoteMySQL(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func (m MySQLConnector) Dialect() string {
+	return DialectMySQL
+}
+
+func (m MySQLConnector) QuoteIdentifier(name string) string {
+	return quoteMySQL(name)
+}
+
+func (m MySQLConnector) CreateTableStatement(table TableStructureResponse) string {
+	return buildCreateTable(mysqlTableDialect, table)
+}
+
+func (m *MySQLConnector) GetObjectDDL(projectID int, req ObjectDDLRequest) (*ObjectDDLResponse, error) {
+	db, err := m.Open(projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	name := qualifiedName(quoteMySQL, req.SchemaName, req.ObjectName)
+
+	var statement string
+	switch req.ObjectType {
+	case ObjectTypeTable:
+		statement, err = mysqlShowCreate(db, "SHOW CREATE TABLE "+name, "Create Table")
+	case ObjectTypeView:
+		statement, err = mysqlShowCreate(db, "SHOW CREATE VIEW "+name, "Create View")
+	case ObjectTypeFunction:
+		statement, err = mysqlShowCreate(db, "SHOW CREATE FUNCTION "+name, "Create Function")
+	case ObjectTypeProcedure:
+		statement, err = mysqlShowCreate(db, "SHOW CREATE PROCEDURE "+name, "Create Procedure")
+	case ObjectTypeIndex:
+		var tables []TableStructureResponse
+		tables, err = loadTables(db, mysqlColumnsQuery, mysqlIndexesQuery, mysqlConstraintsQuery, req.SchemaName, req.SchemaName, req.TableName, req.TableName)
+		if err != nil {
+			return nil, err
+		}
+		if len(tables) == 0 {
+			return nil, ErrObjectNotFound
+		}
+		index, ok := findIndex(tables[0], req.ObjectName)
+		if !ok {
+			return nil, ErrObjectNotFound
+		}
+		statement = createIndexStatement(quoteMySQL, req.SchemaName, req.TableName, index)
+	default:
+		return nil, ErrUnsupportedObjectType
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &ObjectDDLResponse{
+		Dialect:    DialectMySQL,
+		ObjectType: req.ObjectType,
+		SchemaName: req.SchemaName,
+		ObjectName: req.ObjectName,
+		Statement:  statement,
+	}, nil
+}
+
+// mysqlShowCreate runs a SHOW CREATE statement and returns the named column.
+// The result sets of these statements differ per object type, so the row is
+// scanned generically.
+func mysqlShowCreate(db *sql.DB, query string, column string) (string, error) {
+	rows, err := db.Query(query)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && (mysqlErr.Number == 1146 || mysqlErr.Number == 1305 || mysqlErr.Number == 1049) {
+			return "", ErrObjectNotFound
+		}
+		return "", err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return "", err
+		}
+		return "", ErrObjectNotFound
+	}
+
+	values := make([]sql.NullString, len(cols))
+	ptrs := make([]any, len(cols))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	if err := rows.Scan(ptrs...); err != nil {
+		return "", err
+	}
+
+	for i, col := range cols {
+		if col != column {
+			continue
+		}
+		if !values[i].Valid {
+			return "", fmt.Errorf("definition of object is not visible to the connected user")
+		}
+		return values[i].String + ";", nil
+	}
+
+	return "", fmt.Errorf("column %s not found in result", column)
+}
+
+func (m MySQLConnector) CreateSchemaStatement(schemaName string) string {
+	return fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s;", quoteMySQL(schemaName))
+}
+
+func (m MySQLConnector) DropSchemaStatement(schemaName string) string {
+	return fmt.Sprintf("DROP DATABASE %s;", quoteMySQL(schemaName))
+}
+
+func (m MySQLConnector) DropTableStatement(schemaName string, tableName string) string {
+	return fmt.Sprintf("DROP TABLE %s;", qualifiedName(quoteMySQL, schemaName, tableName))
+}
+
+func (m MySQLConnector) RenameTableStatement(schemaName string, tableName string, newName string) string {
+	return fmt.Sprintf(
+		"RENAME TABLE %s TO %s;",
+		qualifiedName(quoteMySQL, schemaName, tableName),
+		qualifiedName(quoteMySQL, schemaName, newName),
+	)
+}
+
+func (m MySQLConnector) AddColumnStatement(schemaName string, tableName string, col ColumnStructureResponse) string {
+	return fmt.Sprintf(
+		"ALTER TABLE %s ADD COLUMN %s;",
+		qualifiedName(quoteMySQL, schemaName, tableName),
+		columnDefinition(mysqlTableDialect, col),
+	)
+}
+
+func (m MySQLConnector) DropColumnStatement(schemaName string, tableName string, columnName string) string {
+	return fmt.Sprintf(
+		"ALTER TABLE %s DROP COLUMN %s;",
+		qualifiedName(quoteMySQL, schemaName, tableName),
+		quoteMySQL(columnName),
+	)
+}
+
+func (m MySQLConnector) RenameColumnStatement(schemaName string, tableName string, columnName string, newName string) string {
+	return fmt.Sprintf(
+		"ALTER TABLE %s RENAME COLUMN %s TO %s;",
+		qualifiedName(quoteMySQL, schemaName, tableName),
+		quoteMySQL(columnName),
+		quoteMySQL(newName),
+	)
+}
+
+// AlterColumnStatements uses MODIFY COLUMN, which restates the complete column
+// definition, so every kind of change results in a single statement.
+func (m MySQLConnector) AlterColumnStatements(schemaName string, tableName string, from ColumnStructureResponse, to ColumnStructureResponse) ([]string, error) {
+	return []string{fmt.Sprintf(
+		"ALTER TABLE %s MODIFY COLUMN %s;",
+		qualifiedName(quoteMySQL, schemaName, tableName),
+		columnDefinition(mysqlTableDialect, to),
+	)}, nil
+}
+
+func (m MySQLConnector) CreateIndexStatement(schemaName string, tableName string, index IndexStructureResponse) string {
+	return createIndexStatement(quoteMySQL, schemaName, tableName, index)
+}
+
+func (m MySQLConnector) DropIndexStatement(schemaName string, tableName string, index IndexStructureResponse) string {
+	table := qualifiedName(quoteMySQL, schemaName, tableName)
+	if index.Primary {
+		return fmt.Sprintf("ALTER TABLE %s DROP PRIMARY KEY;", table)
+	}
+	return fmt.Sprintf("DROP INDEX %s ON %s;", quoteMySQL(index.IndexName), table)
+}
+
+func (m MySQLConnector) AddConstraintStatement(schemaName string, tableName string, constraint ConstraintStructureResponse) string {
+	return fmt.Sprintf(
+		"ALTER TABLE %s ADD %s;",
+		qualifiedName(quoteMySQL, schemaName, tableName),
+		mysqlTableDialect.constraint(constraint),
+	)
+}
+
+func (m MySQLConnector) DropConstraintStatement(schemaName string, tableName string, constraint ConstraintStructureResponse) string {
+	table := qualifiedName(quoteMySQL, schemaName, tableName)
+	name := quoteMySQL(constraint.ConstraintName)
+
+	switch constraint.ConstraintType {
+	case "PRIMARY KEY":
+		return fmt.Sprintf("ALTER TABLE %s DROP PRIMARY KEY;", table)
+	case "FOREIGN KEY":
+		return fmt.Sprintf("ALTER TABLE %s DROP FOREIGN KEY %s;", table, name)
+	case "UNIQUE":
+		return fmt.Sprintf("ALTER TABLE %s DROP INDEX %s;", table, name)
+	default:
+		return fmt.Sprintf("ALTER TABLE %s DROP CHECK %s;", table, name)
+	}
+}
+
+func (m MySQLConnector) JoinStatements(statements []string) string {
+	return strings.Join(statements, "\n\n")
+}
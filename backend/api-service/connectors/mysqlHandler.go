@@ -181,3 +181,234 @@ func (m MySQLConnector) GetDatabaseStructure(projectID int) (*DatabaseStructureR
 
 	return parseDatabaseStructure(rows)
 }
+
+const mysqlSystemSchemas = `('mysql', 'information_schema', 'performance_schema', 'sys')`
+
+const mysqlColumnsQuery = `
+	SELECT
+		c.TABLE_SCHEMA,
+		c.TABLE_NAME,
+		c.COLUMN_NAME,
+		c.DATA_TYPE,
+		c.COLUMN_TYPE,
+		c.IS_NULLABLE,
+		CASE
+			WHEN c.COLUMN_DEFAULT IS NULL THEN NULL
+			WHEN c.COLUMN_DEFAULT LIKE 'CURRENT\_TIMESTAMP%' AND c.DATA_TYPE IN ('datetime', 'timestamp') THEN c.COLUMN_DEFAULT
+			WHEN c.EXTRA LIKE '%DEFAULT_GENERATED%' THEN CONCAT('(', REPLACE(c.COLUMN_DEFAULT, '\\''', ''''), ')')
+			WHEN c.DATA_TYPE IN ('tinyint', 'smallint', 'mediumint', 'int', 'integer', 'bigint', 'decimal', 'numeric',
+			                     'float', 'double', 'real', 'bit', 'year') THEN c.COLUMN_DEFAULT
+			ELSE CONCAT('''', REPLACE(c.COLUMN_DEFAULT, '''', ''''''), '''')
+		END,
+		c.ORDINAL_POSITION,
+		c.EXTRA LIKE '%auto_increment%'
+	FROM INFORMATION_SCHEMA.COLUMNS c
+	JOIN INFORMATION_SCHEMA.TABLES t ON t.TABLE_SCHEMA = c.TABLE_SCHEMA AND t.TABLE_NAME = c.TABLE_NAME
+	WHERE t.TABLE_TYPE = 'BASE TABLE'
+	  AND c.TABLE_SCHEMA NOT IN ` + mysqlSystemSchemas + `
+	  AND (? = '' OR c.TABLE_SCHEMA = ?)
+	  AND (? = '' OR c.TABLE_NAME = ?)
+	ORDER BY c.TABLE_SCHEMA, c.TABLE_NAME, c.ORDINAL_POSITION
+`
+
+const mysqlIndexesQuery = `
+	SELECT
+		s.TABLE_SCHEMA,
+		s.TABLE_NAME,
+		s.INDEX_NAME,
+		s.NON_UNIQUE = 0,
+		s.INDEX_NAME = 'PRIMARY',
+		COALESCE(s.COLUMN_NAME, ''),
+		'',
+		''
+	FROM INFORMATION_SCHEMA.STATISTICS s
+	WHERE s.TABLE_SCHEMA NOT IN ` + mysqlSystemSchemas + `
+	  AND (? = '' OR s.TABLE_SCHEMA = ?)
+	  AND (? = '' OR s.TABLE_NAME = ?)
+	ORDER BY s.TABLE_SCHEMA, s.TABLE_NAME, s.INDEX_NAME, s.SEQ_IN_INDEX
+`
+
+const mysqlConstraintsQuery = `
+	SELECT
+		tc.TABLE_SCHEMA,
+		tc.TABLE_NAME,
+		tc.CONSTRAINT_NAME,
+		tc.CONSTRAINT_TYPE,
+		COALESCE(kcu.COLUMN_NAME, ''),
+		COALESCE(kcu.REFERENCED_TABLE_SCHEMA, ''),
+		COALESCE(kcu.REFERENCED_TABLE_NAME, ''),
+		COALESCE(kcu.REFERENCED_COLUMN_NAME, ''),
+		'',
+		CASE WHEN rc.DELETE_RULE IS NULL OR rc.DELETE_RULE = 'NO ACTION' THEN '' ELSE rc.DELETE_RULE END,
+		CASE WHEN rc.UPDATE_RULE IS NULL OR rc.UPDATE_RULE = 'NO ACTION' THEN '' ELSE rc.UPDATE_RULE END
+	FROM INFORMATION_SCHEMA.TABLE_CONSTRAINTS tc
+	LEFT JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE kcu
+		ON kcu.CONSTRAINT_SCHEMA = tc.CONSTRAINT_SCHEMA
+		AND kcu.CONSTRAINT_NAME = tc.CONSTRAINT_NAME
+		AND kcu.TABLE_SCHEMA = tc.TABLE_SCHEMA
+		AND kcu.TABLE_NAME = tc.TABLE_NAME
+	LEFT JOIN INFORMATION_SCHEMA.REFERENTIAL_CONSTRAINTS rc
+		ON rc.CONSTRAINT_SCHEMA = tc.CONSTRAINT_SCHEMA
+		AND rc.CONSTRAINT_NAME = tc.CONSTRAINT_NAME
+		AND rc.TABLE_NAME = tc.TABLE_NAME
+	WHERE tc.TABLE_SCHEMA NOT IN ` + mysqlSystemSchemas + `
+	  AND (? = '' OR tc.TABLE_SCHEMA = ?)
+	  AND (? = '' OR tc.TABLE_NAME = ?)
+	ORDER BY tc.TABLE_SCHEMA, tc.TABLE_NAME, tc.CONSTRAINT_NAME, kcu.ORDINAL_POSITION
+`
+
+func (m *MySQLConnector) Open(projectID int) (*sql.DB, error) {
+	conStr, err := m.BuildConnectionString(projectID, m.MetaDataClient)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Connect(conStr)
+}
+
+func (m *MySQLConnector) ListSchemas(projectID int) ([]SchemaSummaryResponse, error) {
+	db, err := m.Open(projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	query := `
+		SELECT s.SCHEMA_NAME, COUNT(t.TABLE_NAME)
+		FROM INFORMATION_SCHEMA.SCHEMATA s
+		LEFT JOIN INFORMATION_SCHEMA.TABLES t ON t.TABLE_SCHEMA = s.SCHEMA_NAME AND t.TABLE_TYPE = 'BASE TABLE'
+		WHERE s.SCHEMA_NAME NOT IN ` + mysqlSystemSchemas + `
+		GROUP BY s.SCHEMA_NAME
+		ORDER BY s.SCHEMA_NAME
+	`
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schemas := []SchemaSummaryResponse{}
+	for rows.Next() {
+		var schema SchemaSummaryResponse
+		if err := rows.Scan(&schema.SchemaName, &schema.TableCount); err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, schema)
+	}
+
+	return schemas, rows.Err()
+}
+
+func (m *MySQLConnector) ListTables(projectID int, req TableListRequest) (*TableListResponse, error) {
+	db, err := m.Open(projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	limit, offset := normalizePaging(&req)
+	pattern := likePattern(req.Search)
+
+	response := &TableListResponse{
+		SchemaName: req.SchemaName,
+		Tables:     []TableSummaryResponse{},
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+	}
+
+	countQuery := `
+		SELECT COUNT(*)
+		FROM INFORMATION_SCHEMA.TABLES
+		WHERE TABLE_TYPE = 'BASE TABLE' AND TABLE_SCHEMA = ? AND TABLE_NAME LIKE ?
+	`
+	if err := db.QueryRow(countQuery, req.SchemaName, pattern).Scan(&response.Total); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT TABLE_NAME, COALESCE(TABLE_ROWS, 0), COALESCE(DATA_LENGTH, 0) + COALESCE(INDEX_LENGTH, 0)
+		FROM INFORMATION_SCHEMA.TABLES
+		WHERE TABLE_TYPE = 'BASE TABLE' AND TABLE_SCHEMA = ? AND TABLE_NAME LIKE ?
+		ORDER BY TABLE_NAME
+		LIMIT ? OFFSET ?
+	`
+	rows, err := db.Query(query, req.SchemaName, pattern, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var table TableSummaryResponse
+		if err := rows.Scan(&table.TableName, &table.EstimatedRows, &table.TotalSizeBytes); err != nil {
+			return nil, err
+		}
+		response.Tables = append(response.Tables, table)
+	}
+
+	return response, rows.Err()
+}
+
+func (m *MySQLConnector) GetTableDetail(projectID int, schemaName string, tableName string) (*TableStructureResponse, error) {
+	db, err := m.Open(projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	tables, err := loadTables(db, mysqlColumnsQuery, mysqlIndexesQuery, mysqlConstraintsQuery, schemaName, schemaName, tableName, tableName)
+	if err != nil {
+		return nil, err
+	}
+	if len(tables) == 0 {
+		return nil, ErrTableNotFound
+	}
+
+	return &tables[0], nil
+}
+
+// IntrospectDatabase loads every user table with its columns, indexes and
+// constraints. Unlike GetDatabaseStructure the result is sorted and complete
+// enough to be stored as a snapshot or compared with another structure.
+func (m *MySQLConnector) IntrospectDatabase(projectID int) (*DatabaseStructureResponse, error) {
+	db, err := m.Open(projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	tables, err := loadTables(db, mysqlColumnsQuery, mysqlIndexesQuery, mysqlConstraintsQuery, "", "", "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	return groupTables(tables), nil
+}
+
+// MySQL has no sequences, AUTO_INCREMENT is part of the column.
+const mysqlObjectsQuery = `
+	SELECT TABLE_SCHEMA, TABLE_NAME, 'view'
+	FROM INFORMATION_SCHEMA.VIEWS
+	WHERE TABLE_SCHEMA NOT IN ` + mysqlSystemSchemas + `
+	UNION ALL
+	SELECT ROUTINE_SCHEMA, ROUTINE_NAME, LOWER(ROUTINE_TYPE)
+	FROM INFORMATION_SCHEMA.ROUTINES
+	WHERE ROUTINE_SCHEMA NOT IN ` + mysqlSystemSchemas + `
+	UNION ALL
+	SELECT TRIGGER_SCHEMA, TRIGGER_NAME, 'trigger'
+	FROM INFORMATION_SCHEMA.TRIGGERS
+	WHERE TRIGGER_SCHEMA NOT IN ` + mysqlSystemSchemas + `
+	ORDER BY 1, 3, 2
+`
+
+// ListObjects lists the views, routines, sequences and triggers of the
+// database, sequences first since tables may depend on them.
+func (m *MySQLConnector) ListObjects(projectID int) ([]SchemaObject, error) {
+	db, err := m.Open(projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	return loadObjects(db, mysqlObjectsQuery)
+}
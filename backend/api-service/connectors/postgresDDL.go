@@ -0,0 +1,288 @@
+package connectors
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var postgresTableDialect = tableDialect{
+	quote:         quotePostgres,
+	identity:      "GENERATED BY DEFAULT AS IDENTITY",
+	formatDefault: func(value string) string { return value },
+	constraint: func(c ConstraintStructureResponse) string {
+		// Introspected constraints carry the full pg_get_constraintdef output,
+		// new check constraints only their expression.
+		if c.Definition == "" || (c.ConstraintType == "CHECK" && !strings.HasPrefix(strings.ToUpper(c.Definition), "CHECK")) {
+			return namedConstraint(quotePostgres, c)
+		}
+		return fmt.Sprintf("CONSTRAINT %s %s", quotePostgres(c.ConstraintName), c.Definition)
+	},
+	indexes: func(table TableStructureResponse) []string {
+		var statements []string
+		for _, index := range secondaryIndexes(table) {
+			if index.Definition != "" {
+				statements = append(statements, index.Definition+";")
+				continue
+			}
+			statements = append(statements, createIndexStatement(quotePostgres, table.SchemaName, table.TableName, index))
+		}
+		return statements
+	},
+}
+
+func quotePostgres(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (p PostgresConnector) Dialect() string {
+	return DialectPostgres
+}
+
+func (p PostgresConnector) QuoteIdentifier(name string) string {
+	return quotePostgres(name)
+}
+
+func (p PostgresConnector) CreateTableStatement(table TableStructureResponse) string {
+	return buildCreateTable(postgresTableDialect, table)
+}
+
+func (p *PostgresConnector) GetObjectDDL(projectID int, req ObjectDDLRequest) (*ObjectDDLResponse, error) {
+	db, err := p.Open(projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	name := qualifiedName(quotePostgres, req.SchemaName, req.ObjectName)
+
+	var statement string
+	switch req.ObjectType {
+	case ObjectTypeTable:
+		tables, err := loadTables(db, postgresColumnsQuery, postgresIndexesQuery, postgresConstraintsQuery, req.SchemaName, req.ObjectName)
+		if err != nil {
+			return nil, err
+		}
+		if len(tables) == 0 {
+			return nil, ErrObjectNotFound
+		}
+		statement = p.CreateTableStatement(tables[0])
+
+	case ObjectTypeView:
+		var materialized bool
+		var definition string
+		query := `
+			SELECT c.relkind = 'm', pg_get_viewdef(c.oid, true)
+			FROM pg_class c
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			WHERE n.nspname = $1 AND c.relname = $2 AND c.relkind IN ('v', 'm')
+		`
+		if err := db.QueryRow(query, req.SchemaName, req.ObjectName).Scan(&materialized, &definition); err != nil {
+			return nil, objectQueryError(err)
+		}
+		if materialized {
+			statement = fmt.Sprintf("CREATE MATERIALIZED VIEW %s AS\n%s", name, strings.TrimSpace(definition))
+		} else {
+			statement = fmt.Sprintf("CREATE OR REPLACE VIEW %s AS\n%s", name, strings.TrimSpace(definition))
+		}
+
+	case ObjectTypeIndex:
+		query := `
+			SELECT pg_get_indexdef(c.oid)
+			FROM pg_class c
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			WHERE n.nspname = $1 AND c.relname = $2 AND c.relkind IN ('i', 'I')
+		`
+		if err := db.QueryRow(query, req.SchemaName, req.ObjectName).Scan(&statement); err != nil {
+			return nil, objectQueryError(err)
+		}
+		statement += ";"
+
+	case ObjectTypeFunction, ObjectTypeProcedure:
+		kind := "f"
+		if req.ObjectType == ObjectTypeProcedure {
+			kind = "p"
+		}
+		query := `
+			SELECT pg_get_functiondef(p.oid)
+			FROM pg_proc p
+			JOIN pg_namespace n ON n.oid = p.pronamespace
+			WHERE n.nspname = $1 AND p.proname = $2 AND p.prokind = $3
+			ORDER BY p.oid
+		`
+		rows, err := db.Query(query, req.SchemaName, req.ObjectName, kind)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		var definitions []string
+		for rows.Next() {
+			var definition string
+			if err := rows.Scan(&definition); err != nil {
+				return nil, err
+			}
+			definitions = append(definitions, strings.TrimSpace(definition)+";")
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		if len(definitions) == 0 {
+			return nil, ErrObjectNotFound
+		}
+		statement = strings.Join(definitions, "\n\n")
+
+	case ObjectTypeSequence:
+		var dataType string
+		var start, minValue, maxValue, increment, cache int64
+		var cycle bool
+		query := `
+			SELECT data_type::text, start_value, min_value, max_value, increment_by, cache_size, cycle
+			FROM pg_sequences
+			WHERE schemaname = $1 AND sequencename = $2
+		`
+		if err := db.QueryRow(query, req.SchemaName, req.ObjectName).Scan(&dataType, &start, &minValue, &maxValue, &increment, &cache, &cycle); err != nil {
+			return nil, objectQueryError(err)
+		}
+		statement = fmt.Sprintf(
+			"CREATE SEQUENCE %s AS %s INCREMENT BY %d MINVALUE %d MAXVALUE %d START WITH %d CACHE %d %s;",
+			name, dataType, increment, minValue, maxValue, start, cache, cycleClause(cycle),
+		)
+
+	default:
+		return nil, ErrUnsupportedObjectType
+	}
+
+	return &ObjectDDLResponse{
+		Dialect:    DialectPostgres,
+		ObjectType: req.ObjectType,
+		SchemaName: req.SchemaName,
+		ObjectName: req.ObjectName,
+		Statement:  statement,
+	}, nil
+}
+
+func objectQueryError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrObjectNotFound
+	}
+	return err
+}
+
+func cycleClause(cycle bool) string {
+	if cycle {
+		return "CYCLE"
+	}
+	return "NO CYCLE"
+}
+
+func (p PostgresConnector) CreateSchemaStatement(schemaName string) string {
+	return fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s;", quotePostgres(schemaName))
+}
+
+func (p PostgresConnector) DropSchemaStatement(schemaName string) string {
+	return fmt.Sprintf("DROP SCHEMA %s;", quotePostgres(schemaName))
+}
+
+func (p PostgresConnector) DropTableStatement(schemaName string, tableName string) string {
+	return fmt.Sprintf("DROP TABLE %s;", qualifiedName(quotePostgres, schemaName, tableName))
+}
+
+func (p PostgresConnector) RenameTableStatement(schemaName string, tableName string, newName string) string {
+	return fmt.Sprintf(
+		"ALTER TABLE %s RENAME TO %s;",
+		qualifiedName(quotePostgres, schemaName, tableName),
+		quotePostgres(newName),
+	)
+}
+
+func (p PostgresConnector) AddColumnStatement(schemaName string, tableName string, col ColumnStructureResponse) string {
+	return fmt.Sprintf(
+		"ALTER TABLE %s ADD COLUMN %s;",
+		qualifiedName(quotePostgres, schemaName, tableName),
+		columnDefinition(postgresTableDialect, col),
+	)
+}
+
+func (p PostgresConnector) DropColumnStatement(schemaName string, tableName string, columnName string) string {
+	return fmt.Sprintf(
+		"ALTER TABLE %s DROP COLUMN %s;",
+		qualifiedName(quotePostgres, schemaName, tableName),
+		quotePostgres(columnName),
+	)
+}
+
+func (p PostgresConnector) RenameColumnStatement(schemaName string, tableName string, columnName string, newName string) string {
+	return fmt.Sprintf(
+		"ALTER TABLE %s RENAME COLUMN %s TO %s;",
+		qualifiedName(quotePostgres, schemaName, tableName),
+		quotePostgres(columnName),
+		quotePostgres(newName),
+	)
+}
+
+func (p PostgresConnector) AlterColumnStatements(schemaName string, tableName string, from ColumnStructureResponse, to ColumnStructureResponse) ([]string, error) {
+	table := qualifiedName(quotePostgres, schemaName, tableName)
+	column := quotePostgres(to.ColumnName)
+	prefix := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s", table, column)
+
+	var statements []string
+	if fromType, toType := columnType(from), columnType(to); !strings.EqualFold(fromType, toType) {
+		statements = append(statements, fmt.Sprintf("%s TYPE %s USING %s::%s;", prefix, toType, column, toType))
+	}
+	if from.Nullable != to.Nullable {
+		if to.Nullable {
+			statements = append(statements, prefix+" DROP NOT NULL;")
+		} else {
+			statements = append(statements, prefix+" SET NOT NULL;")
+		}
+	}
+	if from.Identity != to.Identity {
+		if to.Identity {
+			statements = append(statements, prefix+" ADD GENERATED BY DEFAULT AS IDENTITY;")
+		} else {
+			statements = append(statements, prefix+" DROP IDENTITY IF EXISTS;")
+		}
+	}
+	if !sameDefault(from, to) && !to.Identity {
+		if to.DefaultValue == nil {
+			statements = append(statements, prefix+" DROP DEFAULT;")
+		} else {
+			statements = append(statements, fmt.Sprintf("%s SET DEFAULT %s;", prefix, *to.DefaultValue))
+		}
+	}
+
+	return statements, nil
+}
+
+func (p PostgresConnector) CreateIndexStatement(schemaName string, tableName string, index IndexStructureResponse) string {
+	if index.Definition != "" && !index.Primary {
+		return index.Definition + ";"
+	}
+	return createIndexStatement(quotePostgres, schemaName, tableName, index)
+}
+
+func (p PostgresConnector) DropIndexStatement(schemaName string, tableName string, index IndexStructureResponse) string {
+	return fmt.Sprintf("DROP INDEX %s;", qualifiedName(quotePostgres, schemaName, index.IndexName))
+}
+
+func (p PostgresConnector) AddConstraintStatement(schemaName string, tableName string, constraint ConstraintStructureResponse) string {
+	return fmt.Sprintf(
+		"ALTER TABLE %s ADD %s;",
+		qualifiedName(quotePostgres, schemaName, tableName),
+		postgresTableDialect.constraint(constraint),
+	)
+}
+
+func (p PostgresConnector) DropConstraintStatement(schemaName string, tableName string, constraint ConstraintStructureResponse) string {
+	return fmt.Sprintf(
+		"ALTER TABLE %s DROP CONSTRAINT %s;",
+		qualifiedName(quotePostgres, schemaName, tableName),
+		quotePostgres(constraint.ConstraintName),
+	)
+}
+
+func (p PostgresConnector) JoinStatements(statements []string) string {
+	return strings.Join(statements, "\n\n")
+}
@@ -240,3 +240,269 @@ func (p *PostgresConnector) GetDatabaseStructure(projectID int) (*DatabaseStruct
 
 	return &response, nil
 }
+
+const postgresSystemSchemaFilter = `n.nspname NOT IN ('pg_catalog', 'information_schema') AND n.nspname NOT LIKE 'pg_toast%' AND n.nspname NOT LIKE 'pg_temp%'`
+
+const postgresColumnsQuery = `
+	SELECT
+		n.nspname,
+		c.relname,
+		a.attname,
+		format_type(a.atttypid, NULL),
+		format_type(a.atttypid, a.atttypmod),
+		CASE WHEN a.attnotnull THEN 'NO' ELSE 'YES' END,
+		pg_get_expr(d.adbin, d.adrelid),
+		a.attnum,
+		a.attidentity <> ''
+	FROM pg_attribute a
+	JOIN pg_class c ON c.oid = a.attrelid
+	JOIN pg_namespace n ON n.oid = c.relnamespace
+	LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+	WHERE c.relkind IN ('r', 'p')
+	  AND a.attnum > 0
+	  AND NOT a.attisdropped
+	  AND ` + postgresSystemSchemaFilter + `
+	  AND ($1 = '' OR n.nspname = $1)
+	  AND ($2 = '' OR c.relname = $2)
+	ORDER BY n.nspname, c.relname, a.attnum
+`
+
+const postgresIndexesQuery = `
+	SELECT
+		n.nspname,
+		c.relname,
+		i.relname,
+		ix.indisunique,
+		ix.indisprimary,
+		pg_get_indexdef(ix.indexrelid, k.ord, true),
+		COALESCE(pg_get_expr(ix.indpred, ix.indrelid), ''),
+		pg_get_indexdef(ix.indexrelid)
+	FROM pg_index ix
+	JOIN pg_class i ON i.oid = ix.indexrelid
+	JOIN pg_class c ON c.oid = ix.indrelid
+	JOIN pg_namespace n ON n.oid = c.relnamespace
+	CROSS JOIN LATERAL generate_series(1, ix.indnatts::int) AS k(ord)
+	WHERE c.relkind IN ('r', 'p')
+	  AND ` + postgresSystemSchemaFilter + `
+	  AND ($1 = '' OR n.nspname = $1)
+	  AND ($2 = '' OR c.relname = $2)
+	ORDER BY n.nspname, c.relname, i.relname, k.ord
+`
+
+const postgresConstraintsQuery = `
+	SELECT
+		n.nspname,
+		c.relname,
+		con.conname,
+		CASE con.contype
+			WHEN 'p' THEN 'PRIMARY KEY'
+			WHEN 'f' THEN 'FOREIGN KEY'
+			WHEN 'u' THEN 'UNIQUE'
+			ELSE 'CHECK'
+		END,
+		COALESCE(a.attname, ''),
+		COALESCE(fn.nspname, ''),
+		COALESCE(fc.relname, ''),
+		COALESCE(fa.attname, ''),
+		pg_get_constraintdef(con.oid),
+		CASE con.confdeltype
+			WHEN 'r' THEN 'RESTRICT'
+			WHEN 'c' THEN 'CASCADE'
+			WHEN 'n' THEN 'SET NULL'
+			WHEN 'd' THEN 'SET DEFAULT'
+			ELSE ''
+		END,
+		CASE con.confupdtype
+			WHEN 'r' THEN 'RESTRICT'
+			WHEN 'c' THEN 'CASCADE'
+			WHEN 'n' THEN 'SET NULL'
+			WHEN 'd' THEN 'SET DEFAULT'
+			ELSE ''
+		END
+	FROM pg_constraint con
+	JOIN pg_class c ON c.oid = con.conrelid
+	JOIN pg_namespace n ON n.oid = c.relnamespace
+	LEFT JOIN LATERAL unnest(con.conkey) WITH ORDINALITY AS k(attnum, ord) ON true
+	LEFT JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum
+	LEFT JOIN pg_class fc ON fc.oid = con.confrelid
+	LEFT JOIN pg_namespace fn ON fn.oid = fc.relnamespace
+	LEFT JOIN pg_attribute fa ON fa.attrelid = con.confrelid AND fa.attnum = con.confkey[k.ord]
+	WHERE con.contype IN ('p', 'f', 'u', 'c')
+	  AND ` + postgresSystemSchemaFilter + `
+	  AND ($1 = '' OR n.nspname = $1)
+	  AND ($2 = '' OR c.relname = $2)
+	ORDER BY n.nspname, c.relname, con.conname, k.ord
+`
+
+func (p *PostgresConnector) Open(projectID int) (*sql.DB, error) {
+	conStr, err := p.BuildConnectionString(projectID, p.MetaDataClient)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.Connect(conStr)
+}
+
+func (p *PostgresConnector) ListSchemas(projectID int) ([]SchemaSummaryResponse, error) {
+	db, err := p.Open(projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	query := `
+		SELECT n.nspname, COUNT(c.oid)
+		FROM pg_namespace n
+		LEFT JOIN pg_class c ON c.relnamespace = n.oid AND c.relkind IN ('r', 'p')
+		WHERE ` + postgresSystemSchemaFilter + `
+		GROUP BY n.nspname
+		ORDER BY n.nspname
+	`
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schemas := []SchemaSummaryResponse{}
+	for rows.Next() {
+		var schema SchemaSummaryResponse
+		if err := rows.Scan(&schema.SchemaName, &schema.TableCount); err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, schema)
+	}
+
+	return schemas, rows.Err()
+}
+
+func (p *PostgresConnector) ListTables(projectID int, req TableListRequest) (*TableListResponse, error) {
+	db, err := p.Open(projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	limit, offset := normalizePaging(&req)
+	pattern := likePattern(req.Search)
+
+	response := &TableListResponse{
+		SchemaName: req.SchemaName,
+		Tables:     []TableSummaryResponse{},
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+	}
+
+	countQuery := `
+		SELECT COUNT(*)
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'p') AND n.nspname = $1 AND c.relname ILIKE $2
+	`
+	if err := db.QueryRow(countQuery, req.SchemaName, pattern).Scan(&response.Total); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT c.relname, GREATEST(c.reltuples, 0)::bigint, pg_total_relation_size(c.oid)
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'p') AND n.nspname = $1 AND c.relname ILIKE $2
+		ORDER BY c.relname
+		LIMIT $3 OFFSET $4
+	`
+	rows, err := db.Query(query, req.SchemaName, pattern, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var table TableSummaryResponse
+		if err := rows.Scan(&table.TableName, &table.EstimatedRows, &table.TotalSizeBytes); err != nil {
+			return nil, err
+		}
+		response.Tables = append(response.Tables, table)
+	}
+
+	return response, rows.Err()
+}
+
+func (p *PostgresConnector) GetTableDetail(projectID int, schemaName string, tableName string) (*TableStructureResponse, error) {
+	db, err := p.Open(projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	tables, err := loadTables(db, postgresColumnsQuery, postgresIndexesQuery, postgresConstraintsQuery, schemaName, tableName)
+	if err != nil {
+		return nil, err
+	}
+	if len(tables) == 0 {
+		return nil, ErrTableNotFound
+	}
+
+	return &tables[0], nil
+}
+
+// IntrospectDatabase loads every user table with its columns, indexes and
+// constraints. Unlike GetDatabaseStructure the result is sorted and complete
+// enough to be stored as a snapshot or compared with another structure.
+func (p *PostgresConnector) IntrospectDatabase(projectID int) (*DatabaseStructureResponse, error) {
+	db, err := p.Open(projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	tables, err := loadTables(db, postgresColumnsQuery, postgresIndexesQuery, postgresConstraintsQuery, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	return groupTables(tables), nil
+}
+
+// postgresObjectsQuery leaves out sequences of identity columns and objects
+// that belong to an extension.
+const postgresObjectsQuery = `
+	SELECT schema_name, object_name, object_type FROM (
+		SELECT n.nspname AS schema_name, c.relname AS object_name, CASE c.relkind WHEN 'S' THEN 'sequence' ELSE 'view' END AS object_type
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('S', 'v', 'm')
+		  AND ` + postgresSystemSchemaFilter + `
+		  AND NOT EXISTS (
+			SELECT 1 FROM pg_depend d
+			WHERE d.classid = 'pg_class'::regclass AND d.objid = c.oid AND d.deptype IN ('i', 'e'))
+		UNION ALL
+		SELECT n.nspname, p.proname, CASE p.prokind WHEN 'p' THEN 'procedure' ELSE 'function' END
+		FROM pg_proc p
+		JOIN pg_namespace n ON n.oid = p.pronamespace
+		WHERE ` + postgresSystemSchemaFilter + `
+		  AND NOT EXISTS (
+			SELECT 1 FROM pg_depend d
+			WHERE d.classid = 'pg_proc'::regclass AND d.objid = p.oid AND d.deptype = 'e')
+		UNION ALL
+		SELECT n.nspname, t.tgname, 'trigger'
+		FROM pg_trigger t
+		JOIN pg_class c ON c.oid = t.tgrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE NOT t.tgisinternal
+		  AND ` + postgresSystemSchemaFilter + `
+	) objects
+	ORDER BY object_type <> 'sequence', schema_name, object_type, object_name
+`
+
+// ListObjects lists the views, routines, sequences and triggers of the
+// database, sequences first since tables may depend on them.
+func (p *PostgresConnector) ListObjects(projectID int) ([]SchemaObject, error) {
+	db, err := p.Open(projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	return loadObjects(db, postgresObjectsQuery)
+}
@@ -0,0 +1,379 @@
+package connectors
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	mssqlBatchSeparator = regexp.MustCompile(`(?im)^[ \t]*GO[ \t]*(--.*)?$`)
+	mysqlDelimiter      = regexp.MustCompile(`(?i)^[ \t]*DELIMITER[ \t]+(\S+)[ \t]*$`)
+	postgresDollarTag   = regexp.MustCompile(`^\$[A-Za-z_0-9]*\$`)
+)
+
+// splitStatements splits a script on the delimiter while ignoring delimiters
+// inside string literals, quoted identifiers, comments and, for PostgreSQL,
+// dollar quoted bodies. MySQL DELIMITER directives change the delimiter for the
+// statements that follow.
+func splitStatements(script string, dialect string) []string {
+	var statements []string
+	var current strings.Builder
+	delimiter := ";"
+
+	flush := func() {
+		if statement := strings.TrimSpace(current.String()); statement != "" && !onlyComments(statement) {
+			statements = append(statements, statement)
+		}
+		current.Reset()
+	}
+
+	atLineStart := true
+	for i := 0; i < len(script); {
+		if dialect == DialectMySQL && atLineStart {
+			end := strings.IndexByte(script[i:], '\n')
+			line := script[i:]
+			if end >= 0 {
+				line = script[i : i+end]
+			}
+			if match := mysqlDelimiter.FindStringSubmatch(strings.TrimRight(line, "\r")); match != nil {
+				flush()
+				delimiter = match[1]
+				i += len(line)
+				continue
+			}
+		}
+
+		c := script[i]
+		atLineStart = c == '\n'
+
+		switch {
+		case c == '-' && strings.HasPrefix(script[i:], "--"):
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				end = len(script) - i
+			}
+			current.WriteString(script[i : i+end])
+			i += end
+			continue
+
+		case c == '#' && dialect == DialectMySQL:
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				end = len(script) - i
+			}
+			current.WriteString(script[i : i+end])
+			i += end
+			continue
+
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				end = len(script) - i - 2
+			} else {
+				end += 2
+			}
+			current.WriteString(script[i : i+2+end])
+			i += 2 + end
+			continue
+
+		case c == '\'' || c == '"' || (c == '`' && dialect == DialectMySQL) || (c == '[' && dialect == DialectMSSQL):
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			j := i + 1
+			for j < len(script) {
+				if script[j] == '\\' && dialect == DialectMySQL && c != '`' {
+					j += 2
+					continue
+				}
+				if script[j] == closing {
+					if j+1 < len(script) && script[j+1] == closing {
+						j += 2
+						continue
+					}
+					break
+				}
+				j++
+			}
+			if j >= len(script) {
+				j = len(script) - 1
+			}
+			current.WriteString(script[i : j+1])
+			i = j + 1
+			continue
+
+		case c == '$' && dialect == DialectPostgres:
+			if tag := postgresDollarTag.FindString(script[i:]); tag != "" {
+				end := strings.Index(script[i+len(tag):], tag)
+				if end < 0 {
+					end = len(script) - i - len(tag)
+				} else {
+					end += len(tag)
+				}
+				current.WriteString(script[i : i+len(tag)+end])
+				i += len(tag) + end
+				continue
+			}
+		}
+
+		if strings.HasPrefix(script[i:], delimiter) {
+			flush()
+			i += len(delimiter)
+			continue
+		}
+
+		current.WriteByte(c)
+		i++
+	}
+	flush()
+
+	return statements
+}
+
+func onlyComments(statement string) bool {
+	for _, line := range strings.Split(statement, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") && !strings.HasPrefix(line, "#") {
+			if strings.HasPrefix(line, "/*") && strings.HasSuffix(line, "*/") {
+				continue
+			}
+			return false
+		}
+	}
+	return true
+}
+
+// splitBatches splits a T-SQL script on GO batch separators. Statements inside
+// a batch are sent to the server together, as sqlcmd and SSMS do. A GO line
+// inside a string literal, quoted identifier or block comment is no separator.
+func splitBatches(script string) []string {
+	var batches []string
+	var current strings.Builder
+	flush := func() {
+		if batch := strings.TrimSpace(current.String()); batch != "" && !onlyComments(batch) {
+			batches = append(batches, batch)
+		}
+		current.Reset()
+	}
+
+	var state batchState
+	for _, line := range strings.SplitAfter(script, "\n") {
+		if state.inCode() && mssqlBatchSeparator.MatchString(strings.TrimRight(line, "\r\n")) {
+			flush()
+			continue
+		}
+		current.WriteString(line)
+		state.scan(line)
+	}
+	flush()
+
+	return batches
+}
+
+// batchState tracks the literal or block comment a T-SQL line ends in, so the
+// next line is known to start inside it.
+type batchState struct {
+	// closing ends the string literal or quoted identifier, zero in code.
+	closing byte
+	// comments counts open block comments, T-SQL nests them.
+	comments int
+}
+
+func (s batchState) inCode() bool {
+	return s.closing == 0 && s.comments == 0
+}
+
+func (s *batchState) scan(line string) {
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case s.comments > 0:
+			if strings.HasPrefix(line[i:], "/*") {
+				s.comments++
+				i++
+			} else if strings.HasPrefix(line[i:], "*/") {
+				s.comments--
+				i++
+			}
+		case s.closing != 0:
+			if c == s.closing {
+				if i+1 < len(line) && line[i+1] == s.closing {
+					i++
+				} else {
+					s.closing = 0
+				}
+			}
+		case strings.HasPrefix(line[i:], "--"):
+			return
+		case strings.HasPrefix(line[i:], "/*"):
+			s.comments++
+			i++
+		case c == '\'' || c == '"':
+			s.closing = c
+		case c == '[':
+			s.closing = ']'
+		}
+	}
+}
+
+// SplitStatementsFor splits a script like the connector of the dialect does,
+// for callers that only know the dialect of a project.
+func SplitStatementsFor(dialect string, script string) []string {
+	if dialect == DialectMSSQL {
+		return splitBatches(script)
+	}
+	return splitStatements(script, dialect)
+}
+
+// InspectStatements splits a script into single statements to look at them.
+// T-SQL batches are split further on semicolons, so a statement later in a
+// batch is seen too unless it lacks a terminator.
+func InspectStatements(dialect string, script string) []string {
+	if dialect != DialectMSSQL {
+		return splitStatements(script, dialect)
+	}
+	var statements []string
+	for _, batch := range splitBatches(script) {
+		statements = append(statements, splitStatements(batch, DialectMSSQL)...)
+	}
+	return statements
+}
+
+func (p PostgresConnector) SplitStatements(script string) []string {
+	return splitStatements(script, DialectPostgres)
+}
+
+func (m MySQLConnector) SplitStatements(script string) []string {
+	return splitStatements(script, DialectMySQL)
+}
+
+func (m MSSQLConnector) SplitStatements(script string) []string {
+	return splitBatches(script)
+}
+
+// LeadingWords returns the first n upper-cased words of a statement, skipping
+// leading comments.
+func LeadingWords(statement string, n int) string {
+	for {
+		statement = strings.TrimSpace(statement)
+		switch {
+		case strings.HasPrefix(statement, "--"), strings.HasPrefix(statement, "#"):
+			end := strings.IndexByte(statement, '\n')
+			if end < 0 {
+				return ""
+			}
+			statement = statement[end+1:]
+			continue
+		case strings.HasPrefix(statement, "/*"):
+			end := strings.Index(statement, "*/")
+			if end < 0 {
+				return ""
+			}
+			statement = statement[end+2:]
+			continue
+		}
+		break
+	}
+
+	words := strings.Fields(strings.ToUpper(statement))
+	if len(words) > n {
+		words = words[:n]
+	}
+	return strings.Join(words, " ")
+}
+
+var (
+	transactionControl = regexp.MustCompile(
+		`^(BEGIN|START TRANSACTION|COMMIT|END|ROLLBACK|ABORT|PREPARE TRANSACTION|XA|SET (SESSION |@@(SESSION\.)?)?AUTOCOMMIT)\b`)
+	savepointRollback       = regexp.MustCompile(`^ROLLBACK( WORK| TRANSACTION)? TO\b`)
+	mssqlTransactionControl = regexp.MustCompile(
+		`(?i)\b(BEGIN\s+(DISTRIBUTED\s+)?TRAN(SACTION)?|COMMIT|ROLLBACK|SET\s+IMPLICIT_TRANSACTIONS)\b`)
+	mssqlModuleBatch = regexp.MustCompile(`^(CREATE|ALTER|CREATE OR ALTER) (PROC|PROCEDURE|FUNCTION|TRIGGER)\b`)
+)
+
+// ControlsTransaction reports whether a statement begins, commits or rolls back
+// a transaction itself or switches autocommit, which ends the transaction it
+// runs in. A T-SQL batch is checked statement by statement, except when it
+// defines a procedure, function or trigger, whose body is only stored.
+func ControlsTransaction(dialect string, statement string) bool {
+	if dialect == DialectMSSQL {
+		if mssqlModuleBatch.MatchString(LeadingWords(statement, 4)) {
+			return false
+		}
+		return mssqlTransactionControl.MatchString(withoutLiterals(statement))
+	}
+
+	words := LeadingWords(statement, 3)
+	return transactionControl.MatchString(words) && !savepointRollback.MatchString(words)
+}
+
+// withoutLiterals blanks out comments, string literals and bracketed
+// identifiers of a T-SQL batch, so keywords are only found in code.
+func withoutLiterals(batch string) string {
+	var code strings.Builder
+	for i := 0; i < len(batch); {
+		var end int
+		switch {
+		case strings.HasPrefix(batch[i:], "--"):
+			end = strings.IndexByte(batch[i:], '\n')
+		case strings.HasPrefix(batch[i:], "/*"):
+			if end = strings.Index(batch[i+2:], "*/"); end >= 0 {
+				end += 4
+			}
+		case batch[i] == '\'' || batch[i] == '"' || batch[i] == '[':
+			closing := batch[i]
+			if closing == '[' {
+				closing = ']'
+			}
+			end = -1
+			for j := i + 1; j < len(batch); j++ {
+				if batch[j] == closing {
+					if j+1 < len(batch) && batch[j+1] == closing {
+						j++
+						continue
+					}
+					end = j + 1 - i
+					break
+				}
+			}
+		default:
+			code.WriteByte(batch[i])
+			i++
+			continue
+		}
+		if end < 0 {
+			end = len(batch) - i
+		}
+		code.WriteByte(' ')
+		i += end
+	}
+	return code.String()
+}
+
+var postgresNonTransactional = regexp.MustCompile(
+	`(?i)\bCONCURRENTLY\b|^\s*(VACUUM|CREATE\s+DATABASE|DROP\s+DATABASE|ALTER\s+SYSTEM|CREATE\s+TABLESPACE|DROP\s+TABLESPACE|REINDEX\s+(DATABASE|SYSTEM))\b`)
+
+func (p PostgresConnector) SupportsTransaction(statement string) bool {
+	return !ControlsTransaction(DialectPostgres, statement) && !postgresNonTransactional.MatchString(LeadingWords(statement, 4))
+}
+
+// MySQL commits implicitly before and after DDL, so only data manipulation is
+// transactional.
+func (m MySQLConnector) SupportsTransaction(statement string) bool {
+	if ControlsTransaction(DialectMySQL, statement) {
+		return false
+	}
+	switch LeadingWords(statement, 1) {
+	case "INSERT", "UPDATE", "DELETE", "REPLACE", "SELECT", "WITH", "SET":
+		return true
+	}
+	return false
+}
+
+var mssqlNonTransactional = regexp.MustCompile(
+	`(?i)^\s*(CREATE|ALTER|DROP)\s+(DATABASE|FULLTEXT)\b|^\s*(BACKUP|RESTORE|RECONFIGURE)\b`)
+
+func (m MSSQLConnector) SupportsTransaction(statement string) bool {
+	return !ControlsTransaction(DialectMSSQL, statement) && !mssqlNonTransactional.MatchString(LeadingWords(statement, 3))
+}
@@ -0,0 +1,154 @@
+package connectors
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatementsFor(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect string
+		script  string
+		want    []string
+	}{
+		{
+			name:    "semicolons",
+			dialect: DialectPostgres,
+			script:  "CREATE TABLE a (id int);\nCREATE TABLE b (id int);",
+			want:    []string{"CREATE TABLE a (id int)", "CREATE TABLE b (id int)"},
+		},
+		{
+			name:    "delimiters in literals and comments",
+			dialect: DialectPostgres,
+			script:  "INSERT INTO a VALUES ('x;y'); -- no; split\nSELECT \"a;b\" FROM c /* ; */;",
+			want:    []string{"INSERT INTO a VALUES ('x;y')", "-- no; split\nSELECT \"a;b\" FROM c /* ; */"},
+		},
+		{
+			name:    "dollar quoted body",
+			dialect: DialectPostgres,
+			script:  "CREATE FUNCTION f() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql;\nSELECT f();",
+			want:    []string{"CREATE FUNCTION f() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql", "SELECT f()"},
+		},
+		{
+			name:    "tagged dollar quote",
+			dialect: DialectPostgres,
+			script:  "DO $body$ BEGIN PERFORM 1; END $body$;",
+			want:    []string{"DO $body$ BEGIN PERFORM 1; END $body$"},
+		},
+		{
+			name:    "comment only statements are dropped",
+			dialect: DialectPostgres,
+			script:  "-- header\n;\nSELECT 1;\n/* trailer */",
+			want:    []string{"SELECT 1"},
+		},
+		{
+			name:    "mysql delimiter",
+			dialect: DialectMySQL,
+			script:  "DELIMITER $$\nCREATE PROCEDURE p() BEGIN SELECT 1; SELECT 2; END$$\nDELIMITER ;\nSELECT 3;",
+			want:    []string{"CREATE PROCEDURE p() BEGIN SELECT 1; SELECT 2; END", "SELECT 3"},
+		},
+		{
+			name:    "mysql escapes and backticks",
+			dialect: DialectMySQL,
+			script:  "INSERT INTO `a;b` VALUES ('it\\'s; fine');\n# note; here\nSELECT 1;",
+			want:    []string{"INSERT INTO `a;b` VALUES ('it\\'s; fine')", "# note; here\nSELECT 1"},
+		},
+		{
+			name:    "mssql go batches",
+			dialect: DialectMSSQL,
+			script:  "CREATE TABLE a (id int);\nCREATE TABLE b (id int);\nGO\nCREATE PROCEDURE p AS SELECT 1;\ngo -- done\n",
+			want:    []string{"CREATE TABLE a (id int);\nCREATE TABLE b (id int);", "CREATE PROCEDURE p AS SELECT 1;"},
+		},
+		{
+			name:    "mssql go inside a string literal",
+			dialect: DialectMSSQL,
+			script:  "INSERT INTO notes VALUES ('first\nGO\nit''s still\nthe same');\nGO\nSELECT 1",
+			want:    []string{"INSERT INTO notes VALUES ('first\nGO\nit''s still\nthe same');", "SELECT 1"},
+		},
+		{
+			name:    "mssql go inside a nested block comment",
+			dialect: DialectMSSQL,
+			script:  "/* outer\n/* inner */\nGO\n*/\nSELECT 1\nGO\nSELECT 2",
+			want:    []string{"/* outer\n/* inner */\nGO\n*/\nSELECT 1", "SELECT 2"},
+		},
+		{
+			name:    "mssql go after a line comment",
+			dialect: DialectMSSQL,
+			script:  "SELECT 1 -- it's /* not open\nGO\nSELECT [a]]b]\nGO",
+			want:    []string{"SELECT 1 -- it's /* not open", "SELECT [a]]b]"},
+		},
+		{
+			name:    "mssql go inside a word is no separator",
+			dialect: DialectMSSQL,
+			script:  "SELECT 1 AS going\nGO",
+			want:    []string{"SELECT 1 AS going"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitStatementsFor(tt.dialect, tt.script)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitStatementsFor() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInspectStatements(t *testing.T) {
+	got := InspectStatements(DialectMSSQL, "CREATE TABLE a (id int);\nDROP TABLE b;\nGO\nSELECT 1")
+	want := []string{"CREATE TABLE a (id int)", "DROP TABLE b", "SELECT 1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("InspectStatements() = %q, want %q", got, want)
+	}
+}
+
+func TestLeadingWords(t *testing.T) {
+	tests := []struct {
+		statement string
+		n         int
+		want      string
+	}{
+		{"create table a (id int)", 2, "CREATE TABLE"},
+		{"-- comment\n/* block */ drop  table a", 3, "DROP TABLE A"},
+		{"# mysql\nselect", 2, "SELECT"},
+		{"/* unterminated", 1, ""},
+	}
+
+	for _, tt := range tests {
+		if got := LeadingWords(tt.statement, tt.n); got != tt.want {
+			t.Errorf("LeadingWords(%q, %d) = %q, want %q", tt.statement, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestControlsTransaction(t *testing.T) {
+	tests := []struct {
+		dialect   string
+		statement string
+		want      bool
+	}{
+		{DialectPostgres, "COMMIT", true},
+		{DialectPostgres, "-- done\nend", true},
+		{DialectPostgres, "BEGIN", true},
+		{DialectPostgres, "ROLLBACK TO SAVEPOINT s", false},
+		{DialectPostgres, "CREATE TABLE commits (id int)", false},
+		{DialectMySQL, "START TRANSACTION", true},
+		{DialectMySQL, "SET autocommit = 1", true},
+		{DialectMySQL, "SET @@session.autocommit = 0", true},
+		{DialectMySQL, "SET names utf8mb4", false},
+		{DialectMSSQL, "CREATE TABLE a (id int);\nCOMMIT;", true},
+		{DialectMSSQL, "BEGIN TRAN\nUPDATE a SET b = 1", true},
+		{DialectMSSQL, "SET IMPLICIT_TRANSACTIONS ON", true},
+		{DialectMSSQL, "INSERT INTO a VALUES ('commit') -- rollback", false},
+		{DialectMSSQL, "SELECT [commit] FROM a", false},
+		{DialectMSSQL, "CREATE PROCEDURE p AS BEGIN TRAN; COMMIT; END", false},
+	}
+
+	for _, tt := range tests {
+		if got := ControlsTransaction(tt.dialect, tt.statement); got != tt.want {
+			t.Errorf("ControlsTransaction(%s, %q) = %v, want %v", tt.dialect, tt.statement, got, tt.want)
+		}
+	}
+}
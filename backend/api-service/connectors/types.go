@@ -0,0 +1,369 @@
+package connectors
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Logical column types. They are mapped to the native type of each engine, so
+// a version request describes a column the same way for every project.
+const (
+	TypeString      = "string"
+	TypeText        = "text"
+	TypeInt32       = "int32"
+	TypeInt64       = "int64"
+	TypeFloat64     = "float64"
+	TypeDecimal     = "decimal"
+	TypeBool        = "bool"
+	TypeDate        = "date"
+	TypeTimestamp   = "timestamp"
+	TypeTimestampTZ = "timestamptz"
+	TypeUUID        = "uuid"
+	TypeJSON        = "json"
+	TypeBytes       = "bytes"
+	// TypeNative passes an engine specific type through unchanged.
+	TypeNative = "native"
+)
+
+// Default keywords that are translated to the engine's own expression.
+const (
+	DefaultNow  = "now"
+	DefaultUUID = "uuid"
+	DefaultNull = "null"
+)
+
+var (
+	ErrInvalidType    = errors.New("invalid column type")
+	ErrInvalidDefault = errors.New("invalid default value")
+
+	logicalTypePattern = regexp.MustCompile(`^([a-z0-9]+)(?:\((\d+)(?:,\s*(\d+))?\))?$`)
+	nativeTypePattern  = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_ ]*(\(\s*(\d+|max|MAX)(\s*,\s*\d+)?\s*\))?( [A-Za-z ]+)?$`)
+	numberPattern      = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+)
+
+type LogicalType struct {
+	Name      string
+	Length    int
+	Precision int
+	Scale     int
+	// Native is the engine type of TypeNative.
+	Native string
+}
+
+// TypeMapper translates logical types and defaults to one engine.
+type TypeMapper interface {
+	NativeType(t LogicalType) string
+	// DefaultValue returns the default as it is stored in
+	// ColumnStructureResponse.DefaultValue for this engine.
+	DefaultValue(t LogicalType, value string) (string, error)
+}
+
+// ParseLogicalType parses specs like "string(255)", "int64", "decimal(10,2)"
+// or "native:geometry".
+func ParseLogicalType(spec string) (LogicalType, error) {
+	spec = strings.TrimSpace(spec)
+
+	if native, ok := strings.CutPrefix(spec, TypeNative+":"); ok {
+		native = strings.TrimSpace(native)
+		if !nativeTypePattern.MatchString(native) {
+			return LogicalType{}, fmt.Errorf("%w: %q is not a valid native type", ErrInvalidType, native)
+		}
+		return LogicalType{Name: TypeNative, Native: native}, nil
+	}
+
+	match := logicalTypePattern.FindStringSubmatch(strings.ToLower(spec))
+	if match == nil {
+		return LogicalType{}, fmt.Errorf("%w: %q", ErrInvalidType, spec)
+	}
+
+	t := LogicalType{Name: match[1]}
+	first, _ := strconv.Atoi(match[2])
+	second, _ := strconv.Atoi(match[3])
+
+	switch t.Name {
+	case TypeString:
+		if match[2] == "" || match[3] != "" || first < 1 || first > 4000 {
+			return t, fmt.Errorf("%w: string needs a length between 1 and 4000, like string(255)", ErrInvalidType)
+		}
+		t.Length = first
+	case TypeDecimal:
+		if match[2] == "" || first < 1 || first > 38 || second > first {
+			return t, fmt.Errorf("%w: decimal needs a precision between 1 and 38 and a scale up to it, like decimal(10,2)", ErrInvalidType)
+		}
+		t.Precision, t.Scale = first, second
+	case TypeText, TypeInt32, TypeInt64, TypeFloat64, TypeBool, TypeDate,
+		TypeTimestamp, TypeTimestampTZ, TypeUUID, TypeJSON, TypeBytes:
+		if match[2] != "" {
+			return t, fmt.Errorf("%w: %s takes no arguments", ErrInvalidType, t.Name)
+		}
+	default:
+		return t, fmt.Errorf("%w: unknown type %q, use one of string(n), text, int32, int64, float64, decimal(p,s), bool, date, timestamp, timestamptz, uuid, json, bytes or native:<type>", ErrInvalidType, t.Name)
+	}
+
+	return t, nil
+}
+
+func (t LogicalType) String() string {
+	switch t.Name {
+	case TypeString:
+		return fmt.Sprintf("string(%d)", t.Length)
+	case TypeDecimal:
+		return fmt.Sprintf("decimal(%d,%d)", t.Precision, t.Scale)
+	case TypeNative:
+		return TypeNative + ":" + t.Native
+	}
+	return t.Name
+}
+
+func (t LogicalType) numeric() bool {
+	switch t.Name {
+	case TypeInt32, TypeInt64, TypeFloat64, TypeDecimal:
+		return true
+	}
+	return false
+}
+
+func (t LogicalType) temporal() bool {
+	switch t.Name {
+	case TypeDate, TypeTimestamp, TypeTimestampTZ:
+		return true
+	}
+	return false
+}
+
+// parsedDefault is a default value checked against its logical type. Exactly
+// one kind applies: a keyword, a boolean, a number or a string literal.
+type parsedDefault struct {
+	keyword string
+	boolean *bool
+	number  string
+	literal string
+}
+
+func parseDefault(t LogicalType, value string) (parsedDefault, error) {
+	trimmed := strings.TrimSpace(value)
+	keyword := strings.ToLower(trimmed)
+
+	if t.Name == TypeNative {
+		if strings.ContainsAny(trimmed, ";") || strings.Contains(trimmed, "--") || strings.Contains(trimmed, "/*") {
+			return parsedDefault{}, fmt.Errorf("%w: %q", ErrInvalidDefault, value)
+		}
+		return parsedDefault{number: trimmed}, nil
+	}
+
+	switch {
+	case keyword == DefaultNull:
+		return parsedDefault{keyword: DefaultNull}, nil
+	case keyword == DefaultNow && t.temporal():
+		return parsedDefault{keyword: DefaultNow}, nil
+	case keyword == DefaultUUID && t.Name == TypeUUID:
+		return parsedDefault{keyword: DefaultUUID}, nil
+	case t.Name == TypeBool:
+		b, err := strconv.ParseBool(keyword)
+		if err != nil {
+			return parsedDefault{}, fmt.Errorf("%w: %q is not a boolean", ErrInvalidDefault, value)
+		}
+		return parsedDefault{boolean: &b}, nil
+	case t.numeric():
+		if !numberPattern.MatchString(trimmed) || (strings.Contains(trimmed, ".") && (t.Name == TypeInt32 || t.Name == TypeInt64)) {
+			return parsedDefault{}, fmt.Errorf("%w: %q is not a valid %s", ErrInvalidDefault, value, t)
+		}
+		return parsedDefault{number: trimmed}, nil
+	case t.Name == TypeBytes:
+		return parsedDefault{}, fmt.Errorf("%w: bytes columns only support null", ErrInvalidDefault)
+	case t.Name == TypeString && len(value) > t.Length:
+		return parsedDefault{}, fmt.Errorf("%w: %q is longer than %d", ErrInvalidDefault, value, t.Length)
+	}
+
+	if t.temporal() || t.Name == TypeUUID {
+		if strings.ContainsAny(value, "'\\;") {
+			return parsedDefault{}, fmt.Errorf("%w: %q is not a valid %s", ErrInvalidDefault, value, t)
+		}
+	}
+	return parsedDefault{literal: value}, nil
+}
+
+func quoteString(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+func (p PostgresConnector) NativeType(t LogicalType) string {
+	switch t.Name {
+	case TypeString:
+		return fmt.Sprintf("varchar(%d)", t.Length)
+	case TypeText:
+		return "text"
+	case TypeInt32:
+		return "integer"
+	case TypeInt64:
+		return "bigint"
+	case TypeFloat64:
+		return "double precision"
+	case TypeDecimal:
+		return fmt.Sprintf("numeric(%d,%d)", t.Precision, t.Scale)
+	case TypeBool:
+		return "boolean"
+	case TypeDate:
+		return "date"
+	case TypeTimestamp:
+		return "timestamp"
+	case TypeTimestampTZ:
+		return "timestamptz"
+	case TypeUUID:
+		return "uuid"
+	case TypeJSON:
+		return "jsonb"
+	case TypeBytes:
+		return "bytea"
+	}
+	return t.Native
+}
+
+func (p PostgresConnector) DefaultValue(t LogicalType, value string) (string, error) {
+	d, err := parseDefault(t, value)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case d.keyword == DefaultNull:
+		return "NULL", nil
+	case d.keyword == DefaultNow:
+		return "CURRENT_TIMESTAMP", nil
+	case d.keyword == DefaultUUID:
+		return "gen_random_uuid()", nil
+	case d.boolean != nil:
+		return strings.ToUpper(strconv.FormatBool(*d.boolean)), nil
+	case d.number != "":
+		return d.number, nil
+	case t.Name == TypeJSON:
+		return quoteString(d.literal) + "::jsonb", nil
+	}
+	return quoteString(d.literal), nil
+}
+
+// MySQL has no time zone aware type, timestamptz values are stored as UTC in
+// a DATETIME.
+func (m MySQLConnector) NativeType(t LogicalType) string {
+	switch t.Name {
+	case TypeString:
+		return fmt.Sprintf("varchar(%d)", t.Length)
+	case TypeText:
+		return "longtext"
+	case TypeInt32:
+		return "int"
+	case TypeInt64:
+		return "bigint"
+	case TypeFloat64:
+		return "double"
+	case TypeDecimal:
+		return fmt.Sprintf("decimal(%d,%d)", t.Precision, t.Scale)
+	case TypeBool:
+		return "tinyint(1)"
+	case TypeDate:
+		return "date"
+	case TypeTimestamp, TypeTimestampTZ:
+		return "datetime(6)"
+	case TypeUUID:
+		return "char(36)"
+	case TypeJSON:
+		return "json"
+	case TypeBytes:
+		return "longblob"
+	}
+	return t.Native
+}
+
+// TEXT and JSON only accept expression defaults in MySQL, which are
+// parenthesized.
+func (m MySQLConnector) DefaultValue(t LogicalType, value string) (string, error) {
+	d, err := parseDefault(t, value)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case d.keyword == DefaultNull:
+		return "NULL", nil
+	case d.keyword == DefaultNow:
+		if t.Name == TypeDate {
+			return "(CURRENT_DATE)", nil
+		}
+		return "CURRENT_TIMESTAMP(6)", nil
+	case d.keyword == DefaultUUID:
+		return "(UUID())", nil
+	case d.boolean != nil:
+		if *d.boolean {
+			return "1", nil
+		}
+		return "0", nil
+	case d.number != "":
+		return d.number, nil
+	case t.Name == TypeText || t.Name == TypeJSON:
+		return "(" + quoteString(d.literal) + ")", nil
+	}
+	return quoteString(d.literal), nil
+}
+
+func (m MSSQLConnector) NativeType(t LogicalType) string {
+	switch t.Name {
+	case TypeString:
+		return fmt.Sprintf("nvarchar(%d)", t.Length)
+	case TypeText, TypeJSON:
+		return "nvarchar(max)"
+	case TypeInt32:
+		return "int"
+	case TypeInt64:
+		return "bigint"
+	case TypeFloat64:
+		return "float"
+	case TypeDecimal:
+		return fmt.Sprintf("decimal(%d,%d)", t.Precision, t.Scale)
+	case TypeBool:
+		return "bit"
+	case TypeDate:
+		return "date"
+	case TypeTimestamp:
+		return "datetime2"
+	case TypeTimestampTZ:
+		return "datetimeoffset"
+	case TypeUUID:
+		return "uniqueidentifier"
+	case TypeBytes:
+		return "varbinary(max)"
+	}
+	return t.Native
+}
+
+func (m MSSQLConnector) DefaultValue(t LogicalType, value string) (string, error) {
+	d, err := parseDefault(t, value)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case d.keyword == DefaultNull:
+		return "NULL", nil
+	case d.keyword == DefaultNow:
+		switch t.Name {
+		case TypeTimestampTZ:
+			return "SYSDATETIMEOFFSET()", nil
+		case TypeDate:
+			return "CAST(SYSUTCDATETIME() AS date)", nil
+		}
+		return "SYSUTCDATETIME()", nil
+	case d.keyword == DefaultUUID:
+		return "NEWID()", nil
+	case d.boolean != nil:
+		if *d.boolean {
+			return "1", nil
+		}
+		return "0", nil
+	case d.number != "":
+		return d.number, nil
+	}
+	return "N" + quoteString(d.literal), nil
+}
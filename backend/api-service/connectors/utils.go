@@ -4,9 +4,15 @@ import (
 	"database/sql"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+
 	"github.com/jmoiron/sqlx"
 )
 
+var ErrTableNotFound = errors.New("table not found")
+
 func getDatabaseAuth(projectID int, metaDB *sqlx.DB) (map[string]string, error) {
 	var rawJSON string
 
@@ -109,3 +115,298 @@ func parseDatabaseStructure(rows *sql.Rows) (*DatabaseStructureResponse, error)
 
 	return &response, nil
 }
+
+type columnRow struct {
+	SchemaName string
+	TableName  string
+	Column     ColumnStructureResponse
+}
+
+type indexRow struct {
+	SchemaName string
+	TableName  string
+	IndexName  string
+	Unique     bool
+	Primary    bool
+	ColumnName string
+	Predicate  string
+	Definition string
+}
+
+type constraintRow struct {
+	SchemaName       string
+	TableName        string
+	ConstraintName   string
+	ConstraintType   string
+	ColumnName       string
+	ReferencedSchema string
+	ReferencedTable  string
+	ReferencedColumn string
+	Definition       string
+	OnDelete         string
+	OnUpdate         string
+}
+
+func tableKey(schemaName string, tableName string) string {
+	return schemaName + "." + tableName
+}
+
+// assembleTables groups the flat column, index and constraint rows returned by
+// the dialect specific introspection queries into table structures. The rows
+// are expected to be ordered by schema, table and position.
+func assembleTables(columns []columnRow, indexes []indexRow, constraints []constraintRow) []TableStructureResponse {
+	var tables []TableStructureResponse
+	positions := make(map[string]int)
+
+	for _, row := range columns {
+		key := tableKey(row.SchemaName, row.TableName)
+		pos, ok := positions[key]
+		if !ok {
+			tables = append(tables, TableStructureResponse{
+				SchemaName: row.SchemaName,
+				TableName:  row.TableName,
+				Columns:    []ColumnStructureResponse{},
+			})
+			pos = len(tables) - 1
+			positions[key] = pos
+		}
+		tables[pos].Columns = append(tables[pos].Columns, row.Column)
+	}
+
+	for _, row := range indexes {
+		pos, ok := positions[tableKey(row.SchemaName, row.TableName)]
+		if !ok {
+			continue
+		}
+		table := &tables[pos]
+		last := len(table.Indexes) - 1
+		if last < 0 || table.Indexes[last].IndexName != row.IndexName {
+			table.Indexes = append(table.Indexes, IndexStructureResponse{
+				IndexName:  row.IndexName,
+				Columns:    []string{},
+				Unique:     row.Unique,
+				Primary:    row.Primary,
+				Predicate:  row.Predicate,
+				Definition: row.Definition,
+			})
+			last++
+		}
+		if row.ColumnName != "" {
+			table.Indexes[last].Columns = append(table.Indexes[last].Columns, row.ColumnName)
+		}
+	}
+
+	for _, row := range constraints {
+		pos, ok := positions[tableKey(row.SchemaName, row.TableName)]
+		if !ok {
+			continue
+		}
+		table := &tables[pos]
+		last := len(table.Constraints) - 1
+		if last < 0 || table.Constraints[last].ConstraintName != row.ConstraintName {
+			table.Constraints = append(table.Constraints, ConstraintStructureResponse{
+				ConstraintName:   row.ConstraintName,
+				ConstraintType:   row.ConstraintType,
+				ReferencedSchema: row.ReferencedSchema,
+				ReferencedTable:  row.ReferencedTable,
+				OnDelete:         row.OnDelete,
+				OnUpdate:         row.OnUpdate,
+				Definition:       row.Definition,
+			})
+			last++
+		}
+		if row.ColumnName != "" && !containsString(table.Constraints[last].Columns, row.ColumnName) {
+			table.Constraints[last].Columns = append(table.Constraints[last].Columns, row.ColumnName)
+		}
+		if row.ReferencedColumn != "" && !containsString(table.Constraints[last].ReferencedColumns, row.ReferencedColumn) {
+			table.Constraints[last].ReferencedColumns = append(table.Constraints[last].ReferencedColumns, row.ReferencedColumn)
+		}
+	}
+
+	return tables
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizePaging clamps the requested page to sane bounds and returns the
+// resulting limit and offset.
+func normalizePaging(req *TableListRequest) (int, int) {
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 {
+		req.PageSize = 50
+	}
+	if req.PageSize > 500 {
+		req.PageSize = 500
+	}
+	return req.PageSize, (req.Page - 1) * req.PageSize
+}
+
+func scanColumnRows(rows *sql.Rows) ([]columnRow, error) {
+	var out []columnRow
+	for rows.Next() {
+		var row columnRow
+		var nullable string
+		var defaultValue sql.NullString
+		if err := rows.Scan(
+			&row.SchemaName,
+			&row.TableName,
+			&row.Column.ColumnName,
+			&row.Column.DataType,
+			&row.Column.ColumnType,
+			&nullable,
+			&defaultValue,
+			&row.Column.OrdinalPosition,
+			&row.Column.Identity,
+		); err != nil {
+			return nil, err
+		}
+		row.Column.Nullable = nullable == "YES"
+		if defaultValue.Valid {
+			value := defaultValue.String
+			row.Column.DefaultValue = &value
+		}
+		out = append(out, row)
+	}
+	return out, rows.Err()
+}
+
+func scanIndexRows(rows *sql.Rows) ([]indexRow, error) {
+	var out []indexRow
+	for rows.Next() {
+		var row indexRow
+		if err := rows.Scan(
+			&row.SchemaName,
+			&row.TableName,
+			&row.IndexName,
+			&row.Unique,
+			&row.Primary,
+			&row.ColumnName,
+			&row.Predicate,
+			&row.Definition,
+		); err != nil {
+			return nil, err
+		}
+		out = append(out, row)
+	}
+	return out, rows.Err()
+}
+
+func scanConstraintRows(rows *sql.Rows) ([]constraintRow, error) {
+	var out []constraintRow
+	for rows.Next() {
+		var row constraintRow
+		if err := rows.Scan(
+			&row.SchemaName,
+			&row.TableName,
+			&row.ConstraintName,
+			&row.ConstraintType,
+			&row.ColumnName,
+			&row.ReferencedSchema,
+			&row.ReferencedTable,
+			&row.ReferencedColumn,
+			&row.Definition,
+			&row.OnDelete,
+			&row.OnUpdate,
+		); err != nil {
+			return nil, err
+		}
+		out = append(out, row)
+	}
+	return out, rows.Err()
+}
+
+// loadTables runs the three introspection queries with the same arguments and
+// assembles the result.
+func loadTables(db *sql.DB, columnsQuery string, indexesQuery string, constraintsQuery string, args ...any) ([]TableStructureResponse, error) {
+	columnRows, err := db.Query(columnsQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	columns, err := scanColumnRows(columnRows)
+	columnRows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	indexRows, err := db.Query(indexesQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	indexes, err := scanIndexRows(indexRows)
+	indexRows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	constraintRows, err := db.Query(constraintsQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	constraints, err := scanConstraintRows(constraintRows)
+	constraintRows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return assembleTables(columns, indexes, constraints), nil
+}
+
+func likePattern(search string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + replacer.Replace(search) + "%"
+}
+
+// loadObjects runs a query returning schema, name and type of objects.
+func loadObjects(db *sql.DB, query string) ([]SchemaObject, error) {
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	objects := []SchemaObject{}
+	for rows.Next() {
+		var object SchemaObject
+		if err := rows.Scan(&object.SchemaName, &object.ObjectName, &object.ObjectType); err != nil {
+			return nil, err
+		}
+		objects = append(objects, object)
+	}
+
+	return objects, rows.Err()
+}
+
+// groupTables builds a database structure from a list of tables, sorted by
+// schema and table name so the result is stable between introspections.
+func groupTables(tables []TableStructureResponse) *DatabaseStructureResponse {
+	sort.SliceStable(tables, func(i, j int) bool {
+		if tables[i].SchemaName != tables[j].SchemaName {
+			return tables[i].SchemaName < tables[j].SchemaName
+		}
+		return tables[i].TableName < tables[j].TableName
+	})
+
+	response := &DatabaseStructureResponse{Schemas: []SchemaStructureResponse{}}
+	for _, table := range tables {
+		last := len(response.Schemas) - 1
+		if last < 0 || response.Schemas[last].SchemaName != table.SchemaName {
+			response.Schemas = append(response.Schemas, SchemaStructureResponse{
+				SchemaName: table.SchemaName,
+				Tables:     []TableStructureResponse{},
+			})
+			last++
+		}
+		response.Schemas[last].Tables = append(response.Schemas[last].Tables, table)
+	}
+
+	return response
+}
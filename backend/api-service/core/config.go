@@ -6,6 +6,7 @@ import (
 	"github.com/joho/godotenv"
 	"os"
 	"strconv"
+	"time"
 )
 
 func LoadConfig() (*common.Config, error) {
@@ -58,5 +59,23 @@ func LoadConfig() (*common.Config, error) {
 	}
 	config.PsqlDatabase = psqlDatabase
 
+	snapshotInterval := os.Getenv("SNAPSHOT_INTERVAL")
+	if snapshotInterval != "" {
+		interval, err := time.ParseDuration(snapshotInterval)
+		if err != nil {
+			return nil, errors.New("invalid snapshot interval")
+		}
+		config.SnapshotInterval = interval
+	}
+
+	driftInterval := os.Getenv("DRIFT_INTERVAL")
+	if driftInterval != "" {
+		interval, err := time.ParseDuration(driftInterval)
+		if err != nil {
+			return nil, errors.New("invalid drift interval")
+		}
+		config.DriftInterval = interval
+	}
+
 	return config, nil
 }
@@ -84,6 +84,10 @@ func (c *WebContext) BadRequest(msg string) error {
 	return c.JSON(http.StatusBadRequest, msg)
 }
 
+func (c *WebContext) Conflict(msg string) error {
+	return c.JSON(http.StatusConflict, msg)
+}
+
 func (c *WebContext) NotFound(msg string) error {
 	return c.JSON(http.StatusNotFound, msg)
 }
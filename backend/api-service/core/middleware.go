@@ -4,6 +4,7 @@ import (
 	"backend/connectors"
 	_ "context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/jmoiron/sqlx"
 	"strconv"
@@ -13,6 +14,8 @@ import (
 
 const ConnectorKey = "db_connector"
 
+var ErrUnsupportedDatabase = errors.New("unsupported database type")
+
 func WorkerMiddleware(metaDB *sqlx.DB) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
@@ -28,41 +31,12 @@ func WorkerMiddleware(metaDB *sqlx.DB) echo.MiddlewareFunc {
 				return ctx.BadRequest("invalid project_id")
 			}
 
-			var authJSON string
-			query := `SELECT database_auth FROM projects_credentials WHERE project_id = $1`
-			if err := metaDB.QueryRow(query, projectID).Scan(&authJSON); err != nil {
-				return ctx.InternalError(fmt.Sprintf("failed to get credentials: %v", err))
-			}
-
-			var auth connectors.DatabaseAuth
-			if err := json.Unmarshal([]byte(authJSON), &auth); err != nil {
-				return ctx.InternalError(fmt.Sprintf("invalid JSON: %v", err))
-			}
-
-			var connectionType string
-			connectionTypeQuery := `SELECT connection_types.key FROM connection_types JOIN projects p on connection_types.id = p.connection_type WHERE p.id = $1`
-			if err := metaDB.QueryRow(connectionTypeQuery, projectID).Scan(&connectionType); err != nil {
-				return ctx.InternalError(fmt.Sprintf("failed to get credentials: %v", err))
+			conn, err := NewConnector(metaDB, projectID)
+			if errors.Is(err, ErrUnsupportedDatabase) {
+				return ctx.BadRequest(err.Error())
 			}
-
-			fmt.Println(connectionType)
-
-			var conn connectors.DBConnector
-			switch connectionType {
-			case "psql":
-				conn = &connectors.PostgresConnector{
-					MetaDataClient: metaDB,
-				}
-			case "mysql":
-				conn = &connectors.MySQLConnector{
-					MetaDataClient: metaDB,
-				}
-			case "mssql":
-				conn = &connectors.MSSQLConnector{
-					MetaDataClient: metaDB,
-				}
-			default:
-				return ctx.BadRequest("unsupported database type")
+			if err != nil {
+				return ctx.InternalError(err.Error())
 			}
 
 			ctx.Set("db_connector", conn)
@@ -73,6 +47,45 @@ func WorkerMiddleware(metaDB *sqlx.DB) echo.MiddlewareFunc {
 	}
 }
 
+// NewConnector resolves the connection type and credentials of a project and
+// returns the matching connector. It is used by the worker middleware and by
+// code that needs to reach a project's database outside of a worker request.
+func NewConnector(metaDB *sqlx.DB, projectID int) (connectors.DBConnector, error) {
+	var authJSON string
+	query := `SELECT database_auth FROM projects_credentials WHERE project_id = $1`
+	if err := metaDB.QueryRow(query, projectID).Scan(&authJSON); err != nil {
+		return nil, fmt.Errorf("failed to get credentials: %v", err)
+	}
+
+	var auth connectors.DatabaseAuth
+	if err := json.Unmarshal([]byte(authJSON), &auth); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+
+	var connectionType string
+	connectionTypeQuery := `SELECT connection_types.key FROM connection_types JOIN projects p on connection_types.id = p.connection_type WHERE p.id = $1`
+	if err := metaDB.QueryRow(connectionTypeQuery, projectID).Scan(&connectionType); err != nil {
+		return nil, fmt.Errorf("failed to get credentials: %v", err)
+	}
+
+	switch connectionType {
+	case "psql":
+		return &connectors.PostgresConnector{
+			MetaDataClient: metaDB,
+		}, nil
+	case "mysql":
+		return &connectors.MySQLConnector{
+			MetaDataClient: metaDB,
+		}, nil
+	case "mssql":
+		return &connectors.MSSQLConnector{
+			MetaDataClient: metaDB,
+		}, nil
+	default:
+		return nil, ErrUnsupportedDatabase
+	}
+}
+
 func GetConnector(c echo.Context) connectors.DBConnector {
 	conn, ok := c.Get(ConnectorKey).(connectors.DBConnector)
 	if !ok {
@@ -3,6 +3,7 @@ package databaseWorker
 import (
 	"backend/connectors"
 	"backend/core"
+	"errors"
 )
 
 func HandleGetDatabaseVersion(ctx *core.WebContext) error {
@@ -78,3 +79,107 @@ func HandleDatabaseQuery(ctx *core.WebContext) error {
 
 	return ctx.Sucsess(res)
 }
+
+func HandleListSchemas(ctx *core.WebContext) error {
+	conn, projectID, err := getConnectorAndProject(ctx)
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+
+	schemas, err := conn.ListSchemas(projectID)
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+
+	return ctx.Sucsess(schemas)
+}
+
+func HandleListTables(ctx *core.WebContext) error {
+	conn, projectID, err := getConnectorAndProject(ctx)
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+
+	schemaName := ctx.QueryParam("schema")
+	if schemaName == "" {
+		return ctx.BadRequest("schema is required")
+	}
+
+	page, err := queryParamInt(ctx, "page", 1)
+	if err != nil {
+		return ctx.BadRequest("invalid page")
+	}
+
+	pageSize, err := queryParamInt(ctx, "pageSize", 50)
+	if err != nil {
+		return ctx.BadRequest("invalid pageSize")
+	}
+
+	tables, err := conn.ListTables(projectID, connectors.TableListRequest{
+		SchemaName: schemaName,
+		Search:     ctx.QueryParam("search"),
+		Page:       page,
+		PageSize:   pageSize,
+	})
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+
+	return ctx.Sucsess(tables)
+}
+
+func HandleGetTableDetail(ctx *core.WebContext) error {
+	conn, projectID, err := getConnectorAndProject(ctx)
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+
+	schemaName := ctx.QueryParam("schema")
+	tableName := ctx.QueryParam("table")
+	if schemaName == "" || tableName == "" {
+		return ctx.BadRequest("schema and table are required")
+	}
+
+	table, err := conn.GetTableDetail(projectID, schemaName, tableName)
+	if errors.Is(err, connectors.ErrTableNotFound) {
+		return ctx.NotFound(err.Error())
+	}
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+
+	return ctx.Sucsess(table)
+}
+
+func HandleGetObjectDDL(ctx *core.WebContext) error {
+	conn, projectID, err := getConnectorAndProject(ctx)
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+
+	req := connectors.ObjectDDLRequest{
+		ObjectType: ctx.QueryParam("type"),
+		SchemaName: ctx.QueryParam("schema"),
+		ObjectName: ctx.QueryParam("name"),
+		TableName:  ctx.QueryParam("table"),
+	}
+	if req.ObjectType == "" || req.SchemaName == "" || req.ObjectName == "" {
+		return ctx.BadRequest("type, schema and name are required")
+	}
+	if req.ObjectType == connectors.ObjectTypeIndex && req.TableName == "" && conn.Dialect() != connectors.DialectPostgres {
+		return ctx.BadRequest("table is required for indexes")
+	}
+
+	ddl, err := conn.GetObjectDDL(projectID, req)
+	if errors.Is(err, connectors.ErrObjectNotFound) {
+		return ctx.NotFound(err.Error())
+	}
+	if errors.Is(err, connectors.ErrUnsupportedObjectType) {
+		return ctx.BadRequest(err.Error())
+	}
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+
+	return ctx.Sucsess(ddl)
+}
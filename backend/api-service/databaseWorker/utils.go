@@ -1 +1,31 @@
 package databaseWorker
+
+import (
+	"backend/connectors"
+	"backend/core"
+	"errors"
+	"strconv"
+)
+
+func getConnectorAndProject(ctx *core.WebContext) (connectors.DBConnector, int, error) {
+	conn := core.GetConnector(ctx)
+	if conn == nil {
+		return nil, 0, errors.New("database connector not found")
+	}
+
+	projectID, ok := ctx.Get("project_id").(int)
+	if !ok {
+		return nil, 0, errors.New("invalid project_id")
+	}
+
+	return conn, projectID, nil
+}
+
+func queryParamInt(ctx *core.WebContext, name string, fallback int) (int, error) {
+	value := ctx.QueryParam(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	return strconv.Atoi(value)
+}
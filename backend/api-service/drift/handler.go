@@ -0,0 +1,104 @@
+package drift
+
+import (
+	"backend/core"
+	"database/sql"
+	"errors"
+	"strconv"
+)
+
+func HandleCreateCheck(ctx *core.WebContext) error {
+	userID, err := ctx.GetUserId()
+	if err != nil {
+		return ctx.Unauthorized(err.Error())
+	}
+
+	projectID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return ctx.BadRequest("invalid project id")
+	}
+
+	conn, err := core.NewConnector(ctx.GetDb(), projectID)
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+
+	repo := NewRepository(ctx)
+	result, err := repo.Check(conn, CheckRequest{
+		ProjectID: projectID,
+		UserID:    &userID,
+		Source:    SourceManual,
+	})
+	if err != nil {
+		return ctx.InternalError("failed to check drift: " + err.Error())
+	}
+
+	return ctx.Sucsess(result)
+}
+
+func HandleGetChecks(ctx *core.WebContext) error {
+	_, err := ctx.GetUserId()
+	if err != nil {
+		return ctx.Unauthorized(err.Error())
+	}
+
+	projectID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return ctx.BadRequest("invalid project id")
+	}
+
+	limit := 50
+	if value := ctx.QueryParam("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return ctx.BadRequest("invalid limit")
+		}
+		if limit > 500 {
+			limit = 500
+		}
+	}
+
+	offset := 0
+	if value := ctx.QueryParam("offset"); value != "" {
+		offset, err = strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return ctx.BadRequest("invalid offset")
+		}
+	}
+
+	repo := NewRepository(ctx)
+	checks, err := repo.ListChecks(projectID, limit, offset)
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+
+	return ctx.Sucsess(checks)
+}
+
+func HandleGetCheck(ctx *core.WebContext) error {
+	_, err := ctx.GetUserId()
+	if err != nil {
+		return ctx.Unauthorized(err.Error())
+	}
+
+	projectID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return ctx.BadRequest("invalid project id")
+	}
+
+	checkID, err := strconv.Atoi(ctx.Param("checkId"))
+	if err != nil {
+		return ctx.BadRequest("invalid check id")
+	}
+
+	repo := NewRepository(ctx)
+	check, err := repo.GetCheck(projectID, checkID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ctx.NotFound("drift check not found")
+	}
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+
+	return ctx.Sucsess(check)
+}
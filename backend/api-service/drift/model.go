@@ -0,0 +1,63 @@
+package drift
+
+import (
+	"backend/schemaDiff"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	StatusClean   = "clean"
+	StatusDrifted = "drifted"
+	// StatusUnknown means there is no expected state to compare with, because
+	// no version is completed or its snapshot is missing.
+	StatusUnknown = "unknown"
+	StatusError   = "error"
+)
+
+const (
+	SourceManual   = "manual"
+	SourceSchedule = "schedule"
+)
+
+type Check struct {
+	ID         int       `db:"id" json:"id"`
+	ProjectID  int       `db:"project_id" json:"projectId"`
+	VersionID  *int      `db:"version_id" json:"versionId,omitempty"`
+	Version    *string   `db:"version" json:"version,omitempty"`
+	SnapshotID *int      `db:"snapshot_id" json:"snapshotId,omitempty"`
+	Status     string    `db:"status" json:"status"`
+	Message    *string   `db:"message" json:"message,omitempty"`
+	Source     string    `db:"source" json:"source"`
+	CheckedBy  *int      `db:"checked_by" json:"checkedBy,omitempty"`
+	CheckedAt  time.Time `db:"checked_at" json:"checkedAt"`
+}
+
+type CheckDetail struct {
+	Check
+	Diff   *Diff   `db:"diff" json:"diff,omitempty"`
+	Report *string `db:"report" json:"report,omitempty"`
+}
+
+type CheckRequest struct {
+	ProjectID int
+	UserID    *int
+	Source    string
+}
+
+type Diff schemaDiff.SchemaDiff
+
+func (d Diff) Value() (driver.Value, error) {
+	return json.Marshal(d)
+}
+
+func (d *Diff) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(b, d)
+}
@@ -0,0 +1,177 @@
+package drift
+
+import (
+	"backend/connectors"
+	"backend/core"
+	"backend/schemaDiff"
+	"backend/snapshot"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(ctx *core.WebContext) *Repository {
+	return &Repository{db: ctx.GetDb()}
+}
+
+func NewRepositoryFromDb(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+const checkColumns = `d.id, d.project_id, d.version_id, v.version, d.snapshot_id, d.status, d.message, d.source, d.checked_by, d.checked_at`
+
+// Check compares the live structure of a project's target with the snapshot
+// of its latest completed version and records the result. Objects that only
+// exist on the target show up as added, objects missing there as removed.
+func (r *Repository) Check(conn connectors.DBConnector, req CheckRequest) (*CheckDetail, error) {
+	result := CheckDetail{Check: Check{ProjectID: req.ProjectID, Source: req.Source, CheckedBy: req.UserID}}
+
+	expected, err := r.expectedState(&result)
+	if err != nil {
+		return nil, err
+	}
+
+	if expected != nil {
+		live, err := conn.IntrospectDatabase(req.ProjectID)
+		if err != nil {
+			result.Status = StatusError
+			result.Message = message("failed to introspect target database: " + err.Error())
+		} else {
+			diff := schemaDiff.Compare(connectors.WithoutHistoryTable(expected), connectors.WithoutHistoryTable(live))
+			result.Status = StatusClean
+			if diff.HasChanges {
+				result.Status = StatusDrifted
+				report := fmt.Sprintf("--- expected after version %s\n+++ live database\n%s", *result.Version, schemaDiff.Report(diff))
+				result.Report = &report
+			}
+			converted := Diff(diff)
+			result.Diff = &converted
+		}
+	}
+
+	return r.store(result)
+}
+
+// expectedState loads the snapshot of the latest completed version. It returns
+// nil and sets an unknown status when there is nothing to compare with.
+func (r *Repository) expectedState(result *CheckDetail) (*connectors.DatabaseStructureResponse, error) {
+	var latest struct {
+		ID      int    `db:"id"`
+		Version string `db:"version"`
+	}
+	err := r.db.Get(&latest, `
+		SELECT id, version
+		FROM versions
+		WHERE project_id = $1 AND kind = 'versioned' AND state = 'completed'
+		ORDER BY sort_key DESC
+		LIMIT 1`, result.ProjectID)
+	if errors.Is(err, sql.ErrNoRows) {
+		result.Status = StatusUnknown
+		result.Message = message("no completed version to compare with")
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.VersionID, result.Version = &latest.ID, &latest.Version
+
+	detail, err := snapshot.NewRepositoryFromDb(r.db).GetSnapshotForVersion(result.ProjectID, latest.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		result.Status = StatusUnknown
+		result.Message = message(fmt.Sprintf("no snapshot recorded for version %s", latest.Version))
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.SnapshotID = &detail.ID
+
+	structure := connectors.DatabaseStructureResponse(detail.Structure)
+	return &structure, nil
+}
+
+func (r *Repository) store(result CheckDetail) (*CheckDetail, error) {
+	stmt, err := r.db.PrepareNamed(`
+		INSERT INTO drift_checks (project_id, version_id, snapshot_id, status, message, diff, report, source, checked_by)
+		VALUES (:projectId, :versionId, :snapshotId, :status, :message, :diff, :report, :source, :checkedBy)
+		RETURNING id, checked_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	params := map[string]any{
+		"projectId":  result.ProjectID,
+		"versionId":  result.VersionID,
+		"snapshotId": result.SnapshotID,
+		"status":     result.Status,
+		"message":    result.Message,
+		"diff":       nil,
+		"report":     result.Report,
+		"source":     result.Source,
+		"checkedBy":  result.CheckedBy,
+	}
+	if result.Diff != nil {
+		params["diff"] = *result.Diff
+	}
+
+	if err := stmt.QueryRowx(params).Scan(&result.ID, &result.CheckedAt); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (r *Repository) ListChecks(projectID int, limit int, offset int) ([]Check, error) {
+	checks := []Check{}
+
+	stmt, err := r.db.PrepareNamed(`
+		SELECT ` + checkColumns + `
+		FROM drift_checks d
+		LEFT JOIN versions v ON v.id = d.version_id
+		WHERE d.project_id = :projectId
+		ORDER BY d.id DESC
+		LIMIT :limit OFFSET :offset`)
+	if err != nil {
+		return checks, err
+	}
+	defer stmt.Close()
+
+	params := map[string]any{
+		"projectId": projectID,
+		"limit":     limit,
+		"offset":    offset,
+	}
+
+	err = stmt.Select(&checks, params)
+	return checks, err
+}
+
+func (r *Repository) GetCheck(projectID int, checkID int) (*CheckDetail, error) {
+	stmt, err := r.db.PrepareNamed(`
+		SELECT ` + checkColumns + `, d.diff, d.report
+		FROM drift_checks d
+		LEFT JOIN versions v ON v.id = d.version_id
+		WHERE d.project_id = :projectId AND d.id = :id`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var detail CheckDetail
+	if err := stmt.Get(&detail, map[string]any{"projectId": projectID, "id": checkID}); err != nil {
+		return nil, err
+	}
+
+	return &detail, nil
+}
+
+func message(text string) *string {
+	return &text
+}
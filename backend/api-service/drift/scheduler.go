@@ -0,0 +1,70 @@
+package drift
+
+import (
+	"backend/core"
+	"backend/snapshot"
+	"backend/version"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+)
+
+// StartScheduler checks every active project for drift at the given interval.
+// A zero interval disables scheduled checks.
+func StartScheduler(db *sqlx.DB, interval time.Duration, logger echo.Logger) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			checkActiveProjects(db, logger)
+		}
+	}()
+}
+
+func checkActiveProjects(db *sqlx.DB, logger echo.Logger) {
+	repo := NewRepositoryFromDb(db)
+	versions := version.NewRepositoryFromDb(db)
+
+	projectIDs, err := snapshot.NewRepositoryFromDb(db).GetActiveProjectIDs()
+	if err != nil {
+		logger.Errorf("scheduled drift check: failed to load projects: %v", err)
+		return
+	}
+
+	for _, projectID := range projectIDs {
+		// A running migration changes the target on purpose, checking it
+		// halfway would only report the versions being applied.
+		lock, err := versions.GetMigrationLock(projectID)
+		if err != nil {
+			logger.Errorf("scheduled drift check: project %d: %v", projectID, err)
+			continue
+		}
+		if lock != nil && lock.Active {
+			continue
+		}
+
+		conn, err := core.NewConnector(db, projectID)
+		if err != nil {
+			logger.Errorf("scheduled drift check: project %d: %v", projectID, err)
+			continue
+		}
+
+		result, err := repo.Check(conn, CheckRequest{
+			ProjectID: projectID,
+			Source:    SourceSchedule,
+		})
+		if err != nil {
+			logger.Errorf("scheduled drift check: project %d: %v", projectID, err)
+			continue
+		}
+		if result.Status == StatusDrifted {
+			logger.Warnf("scheduled drift check: project %d drifted from version %s", projectID, *result.Version)
+		}
+	}
+}
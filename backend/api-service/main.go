@@ -4,10 +4,15 @@ import (
 	"backend/config"
 	"backend/core"
 	"backend/databaseWorker"
+	"backend/drift"
 	"backend/projects"
 	"backend/release"
+	"backend/schemaDiff"
+	"backend/snapshot"
 	"backend/user"
 	"backend/version"
+	"fmt"
+	"os"
 
 	"github.com/labstack/echo/v4/middleware"
 )
@@ -24,6 +29,17 @@ func main() {
 		panic(err)
 	}
 
+	if len(os.Args) > 1 {
+		if err := runCommand(app.Ctx.GetDb(), os.Args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	snapshot.StartScheduler(app.Ctx.GetDb(), app.Ctx.GetConfig().SnapshotInterval, app.Logger)
+	drift.StartScheduler(app.Ctx.GetDb(), app.Ctx.GetConfig().DriftInterval, app.Logger)
+
 	app.Use(middleware.CORSWithConfig(middleware.DefaultCORSConfig))
 	mainRoot := app.Group("/api/v1")
 	workerRoot := app.Group("/api/v1/database-worker")
@@ -52,16 +68,51 @@ func main() {
 	mainRoot.DELETE("/projects/:id", projects.HandleDeleteProject)
 	mainRoot.POST("/projects/test-connection", projects.HandleTestProjectConnection)
 
+	mainRoot.GET("/projects/:id/snapshots", snapshot.HandleGetSnapshots)
+	mainRoot.GET("/projects/:id/snapshots/:snapshotId", snapshot.HandleGetSnapshot)
+	mainRoot.POST("/projects/:id/snapshots", snapshot.HandleCreateSnapshot)
+	mainRoot.GET("/projects/:id/drift-checks", drift.HandleGetChecks)
+	mainRoot.GET("/projects/:id/drift-checks/:checkId", drift.HandleGetCheck)
+	mainRoot.POST("/projects/:id/drift-checks", drift.HandleCreateCheck)
+
+	mainRoot.POST("/schema-diff", schemaDiff.HandleDiff)
+
 	mainRoot.GET("/release/project/all", release.HandleGetReleasesForProject)
 	mainRoot.GET("/release/project/latest", release.HandleGetLatestReleasesForProject)
 
 	mainRoot.POST("/version/table/create", version.HandleCreateTable)
+	mainRoot.POST("/version/table/update", version.HandleUpdateTable)
+	mainRoot.POST("/version/table/drop", version.HandleDropTable)
+	mainRoot.POST("/version/index", version.HandleChangeIndex)
+	mainRoot.POST("/version/constraint", version.HandleChangeConstraint)
+	mainRoot.POST("/version/diff/create", version.HandleCreateFromDiff)
+	mainRoot.POST("/version/sql", version.HandleCreateRawVersion)
+	mainRoot.POST("/version/plan", version.HandlePlanVersions)
+	mainRoot.POST("/version/apply", version.HandleApplyVersions)
+	mainRoot.POST("/version/rollback", version.HandleRollbackVersions)
+	mainRoot.POST("/version/baseline", version.HandleBaseline)
+	mainRoot.POST("/version/squash", version.HandleSquashVersions)
+	mainRoot.GET("/version/validate", version.HandleValidateVersions)
+	mainRoot.GET("/projects/:id/versions", version.HandleGetVersions)
+	mainRoot.POST("/projects/:id/versions/import", version.HandleImportVersions)
+	mainRoot.GET("/projects/:id/versions/export", version.HandleExportVersions)
+	mainRoot.GET("/projects/:id/versions/:versionId", version.HandleGetVersion)
+	mainRoot.PUT("/projects/:id/versions/:versionId", version.HandleUpdateVersion)
+	mainRoot.DELETE("/projects/:id/versions/:versionId", version.HandleDeleteVersion)
+	mainRoot.GET("/projects/:id/lint-rules", version.HandleGetLintRules)
+	mainRoot.PUT("/projects/:id/lint-rules", version.HandleUpdateLintRules)
+	mainRoot.GET("/projects/:id/migration-lock", version.HandleGetMigrationLock)
+	mainRoot.DELETE("/projects/:id/migration-lock", version.HandleForceUnlock)
 
 	//---------------------------------
 	// WORKER ROUTES
 	//---------------------------------
 	workerRoot.GET("/db-version", databaseWorker.HandleGetDatabaseVersion)
 	workerRoot.GET("/db-structure", databaseWorker.HandleGetDatabaseStructure)
+	workerRoot.GET("/db-schemas", databaseWorker.HandleListSchemas)
+	workerRoot.GET("/db-tables", databaseWorker.HandleListTables)
+	workerRoot.GET("/db-table", databaseWorker.HandleGetTableDetail)
+	workerRoot.GET("/db-object-ddl", databaseWorker.HandleGetObjectDDL)
 	workerRoot.POST("/execute-query", databaseWorker.HandleDatabaseQuery)
 
 	app.Logger.Fatal(app.Start("0.0.0.0:8080"))
@@ -4,6 +4,7 @@ import (
 	"backend/connectors"
 	"backend/core"
 	b64 "encoding/base64"
+	"errors"
 	"golang.org/x/crypto/bcrypt"
 	"strconv"
 )
@@ -149,6 +150,9 @@ func HandleUpdateProject(ctx *core.WebContext) error {
 
 	repo := NewRepository(ctx)
 	if err := repo.UpdateProject(&project); err != nil {
+		if errors.Is(err, ErrSchemeInUse) {
+			return ctx.Conflict(err.Error())
+		}
 		return ctx.InternalError(err.Error())
 	}
 
@@ -12,6 +12,7 @@ type Projects struct {
 	Active         bool      `db:"active" json:"active"`
 	Visibility     string    `db:"visibility" json:"visibility"`
 	ConnectionType int       `db:"connection_type" json:"connectionType"`
+	VersionScheme  string    `db:"version_scheme" json:"versionScheme"`
 }
 
 type CreateProjectRequest struct {
@@ -25,6 +26,7 @@ type CreateProjectMetadataRequest struct {
 	Description    string `db:"description" json:"description"`
 	Visibility     string `db:"visibility" json:"visibility"`
 	ConnectionType int    `db:"connection_type" json:"connectionType"`
+	VersionScheme  string `db:"version_scheme" json:"versionScheme"`
 }
 
 type CreateProjectCredentialsRequest struct {
@@ -3,11 +3,16 @@ package projects
 import (
 	"backend/core"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/jmoiron/sqlx"
 	"strings"
 )
 
+// ErrSchemeInUse is returned when the version scheme of a project with
+// versions changes, their sort keys would no longer compare.
+var ErrSchemeInUse = errors.New("the version scheme cannot change once the project has versions")
+
 type Repository struct {
 	db *sqlx.DB
 }
@@ -73,8 +78,9 @@ func (r *Repository) CreateProject(cpr CreateProjectRequest, ownerID int) (Proje
 	var project Projects
 
 	stmt, err := r.db.PrepareNamed(`
-		INSERT INTO projects (owner_id, name, description, created_at, updated_at, active, visibility, connection_type)
-		VALUES (:owner_id, :name, :description, NOW(), NOW(), :active, :visibility, :connection_type)
+		INSERT INTO projects (owner_id, name, description, created_at, updated_at, active, visibility, connection_type, version_scheme)
+		VALUES (:owner_id, :name, :description, NOW(), NOW(), :active, :visibility, :connection_type,
+		        COALESCE(NULLIF(:version_scheme, ''), 'semver'))
 		RETURNING *`)
 	if err != nil {
 		return project, err
@@ -87,6 +93,7 @@ func (r *Repository) CreateProject(cpr CreateProjectRequest, ownerID int) (Proje
 		"active":          true,
 		"visibility":      cpr.Metadata.Visibility,
 		"connection_type": cpr.Metadata.ConnectionType,
+		"version_scheme":  cpr.Metadata.VersionScheme,
 	}
 
 	err = stmt.Get(&project, params)
@@ -117,8 +124,6 @@ func (r *Repository) CreateProjectCredentials(cpcr CreateProjectCredentialsReque
 
 	_, err = stmt.Exec(params)
 	return err
-
-	return nil
 }
 
 func (r *Repository) CreateProjectMembers(cpmr CreateProjectMembersRequest, projectID int) error {
@@ -144,6 +149,23 @@ func (r *Repository) CreateProjectMembers(cpmr CreateProjectMembersRequest, proj
 }
 
 func (r *Repository) UpdateProject(p *Projects) error {
+	if p.VersionScheme != "" {
+		var inUse bool
+		err := r.db.Get(&inUse, `
+			SELECT EXISTS (
+				SELECT 1
+				FROM projects p
+				JOIN versions v ON v.project_id = p.id AND v.kind = 'versioned'
+				WHERE p.id = $1 AND p.version_scheme <> $2
+			)`, p.ID, p.VersionScheme)
+		if err != nil {
+			return err
+		}
+		if inUse {
+			return ErrSchemeInUse
+		}
+	}
+
 	stmt, err := r.db.PrepareNamed(`
 		UPDATE projects
 		SET name = :name,
@@ -151,7 +173,8 @@ func (r *Repository) UpdateProject(p *Projects) error {
 			updated_at = NOW(),
 			active = :active,
 			visibility = :visibility,
-			connection_type = :connection_type
+			connection_type = :connection_type,
+			version_scheme = COALESCE(NULLIF(:version_scheme, ''), version_scheme)
 		WHERE id = :id`)
 	if err != nil {
 		return err
@@ -164,6 +187,7 @@ func (r *Repository) UpdateProject(p *Projects) error {
 		"active":          p.Active,
 		"visibility":      p.Visibility,
 		"connection_type": p.ConnectionType,
+		"version_scheme":  p.VersionScheme,
 	}
 
 	_, err = stmt.Exec(params)
@@ -16,7 +16,12 @@ func NewRepository(ctx *core.WebContext) *Repository {
 func (r *Repository) GetReleasesForProject(projectId int) ([]Release, error) {
 	var releases []Release
 
-	stmt, err := r.db.PrepareNamed("SELECT * FROM releases WHERE project_id = :projectId ORDER BY id")
+	stmt, err := r.db.PrepareNamed(`
+		SELECT r.*
+		FROM releases r
+		LEFT JOIN versions v ON v.id = r.current_version
+		WHERE r.project_id = :projectId
+		ORDER BY v.sort_key, r.id`)
 	if err != nil {
 		return releases, err
 	}
@@ -36,7 +41,13 @@ func (r *Repository) GetReleasesForProject(projectId int) ([]Release, error) {
 func (r *Repository) GetLatestReleasesForProject(projectId int) (Release, error) {
 	var release Release
 
-	stmt, err := r.db.PrepareNamed("SELECT * FROM releases WHERE project_id = :projectId ORDER BY id DESC LIMIT 1")
+	stmt, err := r.db.PrepareNamed(`
+		SELECT r.*
+		FROM releases r
+		LEFT JOIN versions v ON v.id = r.current_version
+		WHERE r.project_id = :projectId
+		ORDER BY v.sort_key DESC NULLS LAST, r.id DESC
+		LIMIT 1`)
 	if err != nil {
 		return release, err
 	}
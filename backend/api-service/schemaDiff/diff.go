@@ -0,0 +1,326 @@
+package schemaDiff
+
+import (
+	"backend/connectors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Compare returns the differences between two database structures.
+func Compare(from *connectors.DatabaseStructureResponse, to *connectors.DatabaseStructureResponse) SchemaDiff {
+	diff := SchemaDiff{
+		AddedSchemas:   []string{},
+		RemovedSchemas: []string{},
+		AddedTables:    []connectors.TableStructureResponse{},
+		RemovedTables:  []connectors.TableStructureResponse{},
+		ChangedTables:  []TableDiff{},
+	}
+
+	fromSchemas := schemaNames(from)
+	toSchemas := schemaNames(to)
+	for name := range toSchemas {
+		if !fromSchemas[name] {
+			diff.AddedSchemas = append(diff.AddedSchemas, name)
+		}
+	}
+	for name := range fromSchemas {
+		if !toSchemas[name] {
+			diff.RemovedSchemas = append(diff.RemovedSchemas, name)
+		}
+	}
+	sort.Strings(diff.AddedSchemas)
+	sort.Strings(diff.RemovedSchemas)
+
+	fromTables := tablesByKey(from)
+	toTables := tablesByKey(to)
+
+	for _, key := range sortedKeys(toTables) {
+		toTable := toTables[key]
+		fromTable, ok := fromTables[key]
+		if !ok {
+			diff.AddedTables = append(diff.AddedTables, toTable)
+			continue
+		}
+		if tableDiff, changed := compareTables(fromTable, toTable); changed {
+			diff.ChangedTables = append(diff.ChangedTables, tableDiff)
+		}
+	}
+	for _, key := range sortedKeys(fromTables) {
+		if _, ok := toTables[key]; !ok {
+			diff.RemovedTables = append(diff.RemovedTables, fromTables[key])
+		}
+	}
+
+	diff.HasChanges = len(diff.AddedSchemas) > 0 ||
+		len(diff.RemovedSchemas) > 0 ||
+		len(diff.AddedTables) > 0 ||
+		len(diff.RemovedTables) > 0 ||
+		len(diff.ChangedTables) > 0
+
+	return diff
+}
+
+func compareTables(from connectors.TableStructureResponse, to connectors.TableStructureResponse) (TableDiff, bool) {
+	diff := TableDiff{SchemaName: to.SchemaName, TableName: to.TableName}
+
+	fromColumns := make(map[string]connectors.ColumnStructureResponse)
+	for _, col := range from.Columns {
+		fromColumns[col.ColumnName] = col
+	}
+	toColumns := make(map[string]bool)
+	for _, col := range to.Columns {
+		toColumns[col.ColumnName] = true
+		fromCol, ok := fromColumns[col.ColumnName]
+		if !ok {
+			diff.AddedColumns = append(diff.AddedColumns, col)
+			continue
+		}
+		if changes := compareColumns(fromCol, col); len(changes) > 0 {
+			diff.ChangedColumns = append(diff.ChangedColumns, ColumnChange{
+				ColumnName: col.ColumnName,
+				Changes:    changes,
+				From:       fromCol,
+				To:         col,
+			})
+		}
+	}
+	for _, col := range from.Columns {
+		if !toColumns[col.ColumnName] {
+			diff.RemovedColumns = append(diff.RemovedColumns, col)
+		}
+	}
+
+	fromIndexes := make(map[string]connectors.IndexStructureResponse)
+	for _, index := range from.Indexes {
+		fromIndexes[index.IndexName] = index
+	}
+	toIndexes := make(map[string]bool)
+	for _, index := range to.Indexes {
+		toIndexes[index.IndexName] = true
+		fromIndex, ok := fromIndexes[index.IndexName]
+		if !ok {
+			diff.AddedIndexes = append(diff.AddedIndexes, index)
+			continue
+		}
+		if !sameIndex(fromIndex, index) {
+			diff.ChangedIndexes = append(diff.ChangedIndexes, IndexChange{IndexName: index.IndexName, From: fromIndex, To: index})
+		}
+	}
+	for _, index := range from.Indexes {
+		if !toIndexes[index.IndexName] {
+			diff.RemovedIndexes = append(diff.RemovedIndexes, index)
+		}
+	}
+
+	fromConstraints := make(map[string]connectors.ConstraintStructureResponse)
+	for _, constraint := range from.Constraints {
+		fromConstraints[constraint.ConstraintName] = constraint
+	}
+	toConstraints := make(map[string]bool)
+	for _, constraint := range to.Constraints {
+		toConstraints[constraint.ConstraintName] = true
+		fromConstraint, ok := fromConstraints[constraint.ConstraintName]
+		if !ok {
+			diff.AddedConstraints = append(diff.AddedConstraints, constraint)
+			continue
+		}
+		if !sameConstraint(fromConstraint, constraint) {
+			diff.ChangedConstraints = append(diff.ChangedConstraints, ConstraintChange{ConstraintName: constraint.ConstraintName, From: fromConstraint, To: constraint})
+		}
+	}
+	for _, constraint := range from.Constraints {
+		if !toConstraints[constraint.ConstraintName] {
+			diff.RemovedConstraints = append(diff.RemovedConstraints, constraint)
+		}
+	}
+
+	changed := len(diff.AddedColumns) > 0 || len(diff.RemovedColumns) > 0 || len(diff.ChangedColumns) > 0 ||
+		len(diff.AddedIndexes) > 0 || len(diff.RemovedIndexes) > 0 || len(diff.ChangedIndexes) > 0 ||
+		len(diff.AddedConstraints) > 0 || len(diff.RemovedConstraints) > 0 || len(diff.ChangedConstraints) > 0
+
+	return diff, changed
+}
+
+func compareColumns(from connectors.ColumnStructureResponse, to connectors.ColumnStructureResponse) []string {
+	var changes []string
+	if !strings.EqualFold(ColumnType(from), ColumnType(to)) {
+		changes = append(changes, ChangeType)
+	}
+	if from.Nullable != to.Nullable {
+		changes = append(changes, ChangeNullable)
+	}
+	if defaultValue(from) != defaultValue(to) {
+		changes = append(changes, ChangeDefault)
+	}
+	if from.Identity != to.Identity {
+		changes = append(changes, ChangeIdentity)
+	}
+	return changes
+}
+
+func sameIndex(a connectors.IndexStructureResponse, b connectors.IndexStructureResponse) bool {
+	return a.Unique == b.Unique &&
+		a.Primary == b.Primary &&
+		a.Predicate == b.Predicate &&
+		strings.Join(a.Columns, ",") == strings.Join(b.Columns, ",")
+}
+
+func sameConstraint(a connectors.ConstraintStructureResponse, b connectors.ConstraintStructureResponse) bool {
+	return a.ConstraintType == b.ConstraintType &&
+		strings.Join(a.Columns, ",") == strings.Join(b.Columns, ",") &&
+		a.ReferencedSchema == b.ReferencedSchema &&
+		a.ReferencedTable == b.ReferencedTable &&
+		strings.Join(a.ReferencedColumns, ",") == strings.Join(b.ReferencedColumns, ",") &&
+		strings.Join(strings.Fields(a.Definition), " ") == strings.Join(strings.Fields(b.Definition), " ")
+}
+
+// ColumnType returns the full type of a column, falling back to the plain data
+// type for structures that were introspected without it.
+func ColumnType(col connectors.ColumnStructureResponse) string {
+	if col.ColumnType != "" {
+		return col.ColumnType
+	}
+	return col.DataType
+}
+
+func defaultValue(col connectors.ColumnStructureResponse) string {
+	if col.DefaultValue == nil {
+		return ""
+	}
+	return *col.DefaultValue
+}
+
+func schemaNames(structure *connectors.DatabaseStructureResponse) map[string]bool {
+	names := make(map[string]bool)
+	for _, schema := range structure.Schemas {
+		names[schema.SchemaName] = true
+	}
+	return names
+}
+
+func tablesByKey(structure *connectors.DatabaseStructureResponse) map[string]connectors.TableStructureResponse {
+	tables := make(map[string]connectors.TableStructureResponse)
+	for _, schema := range structure.Schemas {
+		for _, table := range schema.Tables {
+			table.SchemaName = schema.SchemaName
+			tables[schema.SchemaName+"."+table.TableName] = table
+		}
+	}
+	return tables
+}
+
+func sortedKeys(tables map[string]connectors.TableStructureResponse) []string {
+	keys := make([]string, 0, len(tables))
+	for key := range tables {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Report renders a diff as a human readable text report.
+func Report(diff SchemaDiff) string {
+	if !diff.HasChanges {
+		return "No differences.\n"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Schemas: %d added, %d removed\n", len(diff.AddedSchemas), len(diff.RemovedSchemas))
+	fmt.Fprintf(&b, "Tables: %d added, %d removed, %d changed\n\n", len(diff.AddedTables), len(diff.RemovedTables), len(diff.ChangedTables))
+
+	for _, name := range diff.AddedSchemas {
+		fmt.Fprintf(&b, "+ schema %s\n", name)
+	}
+	for _, name := range diff.RemovedSchemas {
+		fmt.Fprintf(&b, "- schema %s\n", name)
+	}
+
+	for _, table := range diff.AddedTables {
+		fmt.Fprintf(&b, "+ table %s.%s (%d columns)\n", table.SchemaName, table.TableName, len(table.Columns))
+	}
+	for _, table := range diff.RemovedTables {
+		fmt.Fprintf(&b, "- table %s.%s\n", table.SchemaName, table.TableName)
+	}
+
+	for _, table := range diff.ChangedTables {
+		fmt.Fprintf(&b, "~ table %s.%s\n", table.SchemaName, table.TableName)
+		for _, col := range table.AddedColumns {
+			fmt.Fprintf(&b, "    + column %s %s\n", col.ColumnName, describeColumn(col))
+		}
+		for _, col := range table.RemovedColumns {
+			fmt.Fprintf(&b, "    - column %s %s\n", col.ColumnName, describeColumn(col))
+		}
+		for _, change := range table.ChangedColumns {
+			var parts []string
+			for _, kind := range change.Changes {
+				switch kind {
+				case ChangeType:
+					parts = append(parts, fmt.Sprintf("type %s -> %s", ColumnType(change.From), ColumnType(change.To)))
+				case ChangeNullable:
+					parts = append(parts, fmt.Sprintf("nullable %t -> %t", change.From.Nullable, change.To.Nullable))
+				case ChangeDefault:
+					parts = append(parts, fmt.Sprintf("default %q -> %q", defaultValue(change.From), defaultValue(change.To)))
+				case ChangeIdentity:
+					parts = append(parts, fmt.Sprintf("identity %t -> %t", change.From.Identity, change.To.Identity))
+				}
+			}
+			fmt.Fprintf(&b, "    ~ column %s: %s\n", change.ColumnName, strings.Join(parts, "; "))
+		}
+		for _, index := range table.AddedIndexes {
+			fmt.Fprintf(&b, "    + index %s %s\n", index.IndexName, describeIndex(index))
+		}
+		for _, index := range table.RemovedIndexes {
+			fmt.Fprintf(&b, "    - index %s %s\n", index.IndexName, describeIndex(index))
+		}
+		for _, change := range table.ChangedIndexes {
+			fmt.Fprintf(&b, "    ~ index %s: %s -> %s\n", change.IndexName, describeIndex(change.From), describeIndex(change.To))
+		}
+		for _, constraint := range table.AddedConstraints {
+			fmt.Fprintf(&b, "    + constraint %s %s\n", constraint.ConstraintName, describeConstraint(constraint))
+		}
+		for _, constraint := range table.RemovedConstraints {
+			fmt.Fprintf(&b, "    - constraint %s %s\n", constraint.ConstraintName, describeConstraint(constraint))
+		}
+		for _, change := range table.ChangedConstraints {
+			fmt.Fprintf(&b, "    ~ constraint %s: %s -> %s\n", change.ConstraintName, describeConstraint(change.From), describeConstraint(change.To))
+		}
+	}
+
+	return b.String()
+}
+
+func describeColumn(col connectors.ColumnStructureResponse) string {
+	description := ColumnType(col)
+	if !col.Nullable {
+		description += " not null"
+	}
+	if col.DefaultValue != nil {
+		description += " default " + *col.DefaultValue
+	}
+	return description
+}
+
+func describeIndex(index connectors.IndexStructureResponse) string {
+	description := "(" + strings.Join(index.Columns, ", ") + ")"
+	if index.Primary {
+		description += " primary"
+	} else if index.Unique {
+		description += " unique"
+	}
+	if index.Predicate != "" {
+		description += " where " + index.Predicate
+	}
+	return description
+}
+
+func describeConstraint(constraint connectors.ConstraintStructureResponse) string {
+	description := strings.ToLower(constraint.ConstraintType) + " (" + strings.Join(constraint.Columns, ", ") + ")"
+	if constraint.ReferencedTable != "" {
+		description += fmt.Sprintf(" references %s.%s (%s)", constraint.ReferencedSchema, constraint.ReferencedTable, strings.Join(constraint.ReferencedColumns, ", "))
+	}
+	if constraint.ConstraintType == "CHECK" && constraint.Definition != "" {
+		description = constraint.Definition
+	}
+	return description
+}
@@ -0,0 +1,38 @@
+package schemaDiff
+
+import (
+	"backend/core"
+	"errors"
+	"net/http"
+)
+
+func HandleDiff(ctx *core.WebContext) error {
+	_, err := ctx.GetUserId()
+	if err != nil {
+		return ctx.Unauthorized(err.Error())
+	}
+
+	var req DiffRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.BadRequest("invalid input")
+	}
+
+	if req.From.ProjectID == 0 || req.To.ProjectID == 0 {
+		return ctx.BadRequest("from.projectId and to.projectId are required")
+	}
+
+	repo := NewRepository(ctx)
+	result, err := repo.Diff(req)
+	if errors.Is(err, ErrStateNotFound) {
+		return ctx.NotFound(err.Error())
+	}
+	if err != nil {
+		return ctx.InternalError("failed to diff schemas: " + err.Error())
+	}
+
+	if ctx.QueryParam("format") == "text" {
+		return ctx.String(http.StatusOK, result.Report)
+	}
+
+	return ctx.Sucsess(result)
+}
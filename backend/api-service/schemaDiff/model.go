@@ -0,0 +1,74 @@
+package schemaDiff
+
+import "backend/connectors"
+
+type StateSource struct {
+	ProjectID  int  `json:"projectId"`
+	SnapshotID *int `json:"snapshotId,omitempty"`
+	VersionID  *int `json:"versionId,omitempty"`
+}
+
+type DiffRequest struct {
+	From          StateSource       `json:"from"`
+	To            StateSource       `json:"to"`
+	SchemaMapping map[string]string `json:"schemaMapping,omitempty"`
+}
+
+type DiffResponse struct {
+	From   string     `json:"from"`
+	To     string     `json:"to"`
+	Diff   SchemaDiff `json:"diff"`
+	Report string     `json:"report"`
+}
+
+// SchemaDiff describes the changes needed to get from one structure to
+// another. Added objects exist only in the target, removed objects only in
+// the source.
+type SchemaDiff struct {
+	HasChanges     bool                                `json:"hasChanges"`
+	AddedSchemas   []string                            `json:"addedSchemas"`
+	RemovedSchemas []string                            `json:"removedSchemas"`
+	AddedTables    []connectors.TableStructureResponse `json:"addedTables"`
+	RemovedTables  []connectors.TableStructureResponse `json:"removedTables"`
+	ChangedTables  []TableDiff                         `json:"changedTables"`
+}
+
+type TableDiff struct {
+	SchemaName         string                                   `json:"schemaName"`
+	TableName          string                                   `json:"tableName"`
+	AddedColumns       []connectors.ColumnStructureResponse     `json:"addedColumns,omitempty"`
+	RemovedColumns     []connectors.ColumnStructureResponse     `json:"removedColumns,omitempty"`
+	ChangedColumns     []ColumnChange                           `json:"changedColumns,omitempty"`
+	AddedIndexes       []connectors.IndexStructureResponse      `json:"addedIndexes,omitempty"`
+	RemovedIndexes     []connectors.IndexStructureResponse      `json:"removedIndexes,omitempty"`
+	ChangedIndexes     []IndexChange                            `json:"changedIndexes,omitempty"`
+	AddedConstraints   []connectors.ConstraintStructureResponse `json:"addedConstraints,omitempty"`
+	RemovedConstraints []connectors.ConstraintStructureResponse `json:"removedConstraints,omitempty"`
+	ChangedConstraints []ConstraintChange                       `json:"changedConstraints,omitempty"`
+}
+
+type ColumnChange struct {
+	ColumnName string                             `json:"columnName"`
+	Changes    []string                           `json:"changes"`
+	From       connectors.ColumnStructureResponse `json:"from"`
+	To         connectors.ColumnStructureResponse `json:"to"`
+}
+
+type IndexChange struct {
+	IndexName string                            `json:"indexName"`
+	From      connectors.IndexStructureResponse `json:"from"`
+	To        connectors.IndexStructureResponse `json:"to"`
+}
+
+type ConstraintChange struct {
+	ConstraintName string                                 `json:"constraintName"`
+	From           connectors.ConstraintStructureResponse `json:"from"`
+	To             connectors.ConstraintStructureResponse `json:"to"`
+}
+
+const (
+	ChangeType     = "type"
+	ChangeNullable = "nullable"
+	ChangeDefault  = "default"
+	ChangeIdentity = "identity"
+)
@@ -0,0 +1,89 @@
+package schemaDiff
+
+import (
+	"backend/connectors"
+	"backend/core"
+	"backend/snapshot"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var ErrStateNotFound = errors.New("schema state not found")
+
+type Repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(ctx *core.WebContext) *Repository {
+	return NewRepositoryFromDb(ctx.GetDb())
+}
+
+func NewRepositoryFromDb(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// LoadState returns the structure described by a source together with a
+// short label used in reports. A source without a snapshot or version refers
+// to the live database of the project.
+func (r *Repository) LoadState(source StateSource) (*connectors.DatabaseStructureResponse, string, error) {
+	snapshots := snapshot.NewRepositoryFromDb(r.db)
+
+	switch {
+	case source.SnapshotID != nil:
+		detail, err := snapshots.GetSnapshot(source.ProjectID, *source.SnapshotID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, "", fmt.Errorf("%w: snapshot %d of project %d", ErrStateNotFound, *source.SnapshotID, source.ProjectID)
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		structure := connectors.DatabaseStructureResponse(detail.Structure)
+		return &structure, fmt.Sprintf("project %d snapshot %d", source.ProjectID, detail.ID), nil
+
+	case source.VersionID != nil:
+		detail, err := snapshots.GetSnapshotForVersion(source.ProjectID, *source.VersionID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, "", fmt.Errorf("%w: no snapshot recorded for version %d of project %d", ErrStateNotFound, *source.VersionID, source.ProjectID)
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		structure := connectors.DatabaseStructureResponse(detail.Structure)
+		return &structure, fmt.Sprintf("project %d version %d (snapshot %d)", source.ProjectID, *source.VersionID, detail.ID), nil
+
+	default:
+		conn, err := core.NewConnector(r.db, source.ProjectID)
+		if err != nil {
+			return nil, "", err
+		}
+		structure, err := conn.IntrospectDatabase(source.ProjectID)
+		if err != nil {
+			return nil, "", err
+		}
+		return structure, fmt.Sprintf("project %d live database", source.ProjectID), nil
+	}
+}
+
+func (r *Repository) Diff(req DiffRequest) (*DiffResponse, error) {
+	from, fromLabel, err := r.LoadState(req.From)
+	if err != nil {
+		return nil, err
+	}
+
+	to, toLabel, err := r.LoadState(req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := Compare(from, RenameSchemas(to, req.SchemaMapping))
+
+	return &DiffResponse{
+		From:   fromLabel,
+		To:     toLabel,
+		Diff:   diff,
+		Report: fmt.Sprintf("--- %s\n+++ %s\n%s", fromLabel, toLabel, Report(diff)),
+	}, nil
+}
@@ -0,0 +1,265 @@
+package schemaDiff
+
+import (
+	"backend/connectors"
+	"fmt"
+)
+
+type DestructiveChange struct {
+	Kind        string `json:"kind"`
+	Object      string `json:"object"`
+	Description string `json:"description"`
+}
+
+type MigrationScript struct {
+	Statements  []string            `json:"statements"`
+	Script      string              `json:"script"`
+	Destructive []DestructiveChange `json:"destructive"`
+	Warnings    []string            `json:"warnings"`
+}
+
+// GenerateMigration returns the statements that turn the `from` structure into
+// the `to` structure. Constraints and indexes are dropped before columns and
+// tables are changed and recreated afterwards, foreign keys last, so the
+// statements can run in order against a database in the `from` state.
+func GenerateMigration(gen connectors.SchemaChangeGenerator, from *connectors.DatabaseStructureResponse, to *connectors.DatabaseStructureResponse) MigrationScript {
+	diff := Compare(from, to)
+	fromTables := tablesByKey(from)
+	toTables := tablesByKey(to)
+
+	script := MigrationScript{
+		Statements:  []string{},
+		Destructive: []DestructiveChange{},
+		Warnings:    []string{},
+	}
+	add := func(statements ...string) {
+		script.Statements = append(script.Statements, statements...)
+	}
+	destructive := func(kind string, object string, description string) {
+		script.Destructive = append(script.Destructive, DestructiveChange{Kind: kind, Object: object, Description: description})
+	}
+
+	for _, schemaName := range diff.AddedSchemas {
+		add(gen.CreateSchemaStatement(schemaName))
+	}
+
+	// Drop changed and removed constraints, foreign keys first.
+	for _, foreignKeys := range []bool{true, false} {
+		for _, table := range diff.ChangedTables {
+			for _, constraint := range table.RemovedConstraints {
+				if (constraint.ConstraintType == "FOREIGN KEY") == foreignKeys {
+					add(gen.DropConstraintStatement(table.SchemaName, table.TableName, constraint))
+				}
+			}
+			for _, change := range table.ChangedConstraints {
+				if (change.From.ConstraintType == "FOREIGN KEY") == foreignKeys {
+					add(gen.DropConstraintStatement(table.SchemaName, table.TableName, change.From))
+				}
+			}
+		}
+	}
+
+	for _, table := range diff.ChangedTables {
+		key := table.SchemaName + "." + table.TableName
+		for _, index := range table.RemovedIndexes {
+			if !constraintIndex(fromTables[key], index) {
+				add(gen.DropIndexStatement(table.SchemaName, table.TableName, index))
+			}
+		}
+		for _, change := range table.ChangedIndexes {
+			if !constraintIndex(fromTables[key], change.From) {
+				add(gen.DropIndexStatement(table.SchemaName, table.TableName, change.From))
+			}
+		}
+	}
+
+	for _, table := range orderByDependencies(diff.AddedTables) {
+		add(gen.CreateTableStatement(table))
+	}
+
+	for _, table := range diff.ChangedTables {
+		name := table.SchemaName + "." + table.TableName
+		for _, col := range table.AddedColumns {
+			add(gen.AddColumnStatement(table.SchemaName, table.TableName, col))
+			if !col.Nullable && col.DefaultValue == nil && !col.Identity {
+				script.Warnings = append(script.Warnings, fmt.Sprintf("column %s.%s is added as NOT NULL without a default and fails on tables with rows", name, col.ColumnName))
+			}
+		}
+		for _, change := range table.ChangedColumns {
+			statements, err := gen.AlterColumnStatements(table.SchemaName, table.TableName, change.From, change.To)
+			if err != nil {
+				script.Warnings = append(script.Warnings, fmt.Sprintf("%s.%s: %v", name, change.ColumnName, err))
+				continue
+			}
+			add(statements...)
+
+			for _, kind := range change.Changes {
+				switch kind {
+				case ChangeType:
+					destructive("alter_column_type", name+"."+change.ColumnName,
+						fmt.Sprintf("type changes from %s to %s, existing values may be truncated or fail to convert", ColumnType(change.From), ColumnType(change.To)))
+				case ChangeNullable:
+					if !change.To.Nullable {
+						script.Warnings = append(script.Warnings, fmt.Sprintf("column %s.%s becomes NOT NULL and fails if it contains NULL values", name, change.ColumnName))
+					}
+				}
+			}
+		}
+		for _, col := range table.RemovedColumns {
+			add(gen.DropColumnStatement(table.SchemaName, table.TableName, col.ColumnName))
+			destructive("drop_column", name+"."+col.ColumnName, "column and its data are dropped")
+		}
+	}
+
+	// Recreate changed and added indexes and constraints, foreign keys last.
+	for _, table := range diff.ChangedTables {
+		key := table.SchemaName + "." + table.TableName
+		for _, index := range table.AddedIndexes {
+			if !constraintIndex(toTables[key], index) {
+				add(gen.CreateIndexStatement(table.SchemaName, table.TableName, index))
+			}
+		}
+		for _, change := range table.ChangedIndexes {
+			if !constraintIndex(toTables[key], change.To) {
+				add(gen.CreateIndexStatement(table.SchemaName, table.TableName, change.To))
+			}
+		}
+	}
+	for _, foreignKeys := range []bool{false, true} {
+		for _, table := range diff.ChangedTables {
+			for _, constraint := range table.AddedConstraints {
+				if (constraint.ConstraintType == "FOREIGN KEY") == foreignKeys {
+					add(gen.AddConstraintStatement(table.SchemaName, table.TableName, constraint))
+				}
+			}
+			for _, change := range table.ChangedConstraints {
+				if (change.To.ConstraintType == "FOREIGN KEY") == foreignKeys {
+					add(gen.AddConstraintStatement(table.SchemaName, table.TableName, change.To))
+				}
+			}
+		}
+	}
+
+	removed := orderByDependencies(diff.RemovedTables)
+	for i := len(removed) - 1; i >= 0; i-- {
+		table := removed[i]
+		add(gen.DropTableStatement(table.SchemaName, table.TableName))
+		destructive("drop_table", table.SchemaName+"."+table.TableName, "table and its data are dropped")
+	}
+
+	for _, schemaName := range diff.RemovedSchemas {
+		add(gen.DropSchemaStatement(schemaName))
+		destructive("drop_schema", schemaName, "schema and all objects in it are dropped")
+	}
+
+	script.Script = gen.JoinStatements(script.Statements)
+
+	return script
+}
+
+// constraintIndex reports whether an index is created implicitly by a
+// primary key or unique constraint of the table and therefore handled
+// together with the constraint.
+func constraintIndex(table connectors.TableStructureResponse, index connectors.IndexStructureResponse) bool {
+	if index.Primary {
+		return true
+	}
+	for _, constraint := range table.Constraints {
+		if constraint.ConstraintName == index.IndexName {
+			return true
+		}
+	}
+	return false
+}
+
+// orderByDependencies sorts tables so that tables referenced by foreign keys
+// come before the tables referencing them. Tables in a reference cycle keep
+// their original order.
+func orderByDependencies(tables []connectors.TableStructureResponse) []connectors.TableStructureResponse {
+	byKey := make(map[string]connectors.TableStructureResponse)
+	for _, table := range tables {
+		byKey[table.SchemaName+"."+table.TableName] = table
+	}
+
+	ordered := make([]connectors.TableStructureResponse, 0, len(tables))
+	state := make(map[string]int)
+
+	var visit func(key string)
+	visit = func(key string) {
+		if state[key] != 0 {
+			return
+		}
+		state[key] = 1
+		table := byKey[key]
+		for _, constraint := range table.Constraints {
+			if constraint.ConstraintType != "FOREIGN KEY" {
+				continue
+			}
+			refSchema := constraint.ReferencedSchema
+			if refSchema == "" {
+				refSchema = table.SchemaName
+			}
+			refKey := refSchema + "." + constraint.ReferencedTable
+			if _, ok := byKey[refKey]; ok && refKey != key {
+				visit(refKey)
+			}
+		}
+		state[key] = 2
+		ordered = append(ordered, table)
+	}
+
+	for _, table := range tables {
+		visit(table.SchemaName + "." + table.TableName)
+	}
+
+	return ordered
+}
+
+// RenameSchemas maps schema names of a structure, for example to compare a
+// staging database with a production database whose schema or database name
+// differs. Index definitions are dropped for renamed schemas because they
+// contain the original schema name and are regenerated from their columns.
+func RenameSchemas(structure *connectors.DatabaseStructureResponse, mapping map[string]string) *connectors.DatabaseStructureResponse {
+	if len(mapping) == 0 {
+		return structure
+	}
+
+	rename := func(name string) string {
+		if mapped, ok := mapping[name]; ok {
+			return mapped
+		}
+		return name
+	}
+
+	renamed := &connectors.DatabaseStructureResponse{Schemas: []connectors.SchemaStructureResponse{}}
+	for _, schema := range structure.Schemas {
+		_, schemaRenamed := mapping[schema.SchemaName]
+		newSchema := connectors.SchemaStructureResponse{
+			SchemaName: rename(schema.SchemaName),
+			Tables:     []connectors.TableStructureResponse{},
+		}
+		for _, table := range schema.Tables {
+			table.SchemaName = newSchema.SchemaName
+			if schemaRenamed {
+				indexes := make([]connectors.IndexStructureResponse, len(table.Indexes))
+				for i, index := range table.Indexes {
+					index.Definition = ""
+					indexes[i] = index
+				}
+				table.Indexes = indexes
+			}
+			constraints := make([]connectors.ConstraintStructureResponse, len(table.Constraints))
+			for i, constraint := range table.Constraints {
+				if constraint.ReferencedSchema != "" {
+					constraint.ReferencedSchema = rename(constraint.ReferencedSchema)
+				}
+				constraints[i] = constraint
+			}
+			table.Constraints = constraints
+			newSchema.Tables = append(newSchema.Tables, table)
+		}
+		renamed.Schemas = append(renamed.Schemas, newSchema)
+	}
+
+	return renamed
+}
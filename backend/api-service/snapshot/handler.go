@@ -0,0 +1,104 @@
+package snapshot
+
+import (
+	"backend/core"
+	"database/sql"
+	"errors"
+	"strconv"
+)
+
+func HandleCreateSnapshot(ctx *core.WebContext) error {
+	userID, err := ctx.GetUserId()
+	if err != nil {
+		return ctx.Unauthorized(err.Error())
+	}
+
+	projectID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return ctx.BadRequest("invalid project id")
+	}
+
+	conn, err := core.NewConnector(ctx.GetDb(), projectID)
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+
+	repo := NewRepository(ctx)
+	result, err := repo.Capture(conn, CaptureRequest{
+		ProjectID: projectID,
+		UserID:    &userID,
+		Source:    SourceManual,
+	})
+	if err != nil {
+		return ctx.InternalError("failed to capture snapshot: " + err.Error())
+	}
+
+	return ctx.Sucsess(result)
+}
+
+func HandleGetSnapshots(ctx *core.WebContext) error {
+	_, err := ctx.GetUserId()
+	if err != nil {
+		return ctx.Unauthorized(err.Error())
+	}
+
+	projectID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return ctx.BadRequest("invalid project id")
+	}
+
+	limit := 50
+	if value := ctx.QueryParam("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return ctx.BadRequest("invalid limit")
+		}
+		if limit > 500 {
+			limit = 500
+		}
+	}
+
+	offset := 0
+	if value := ctx.QueryParam("offset"); value != "" {
+		offset, err = strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return ctx.BadRequest("invalid offset")
+		}
+	}
+
+	repo := NewRepository(ctx)
+	snapshots, err := repo.ListSnapshots(projectID, limit, offset)
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+
+	return ctx.Sucsess(snapshots)
+}
+
+func HandleGetSnapshot(ctx *core.WebContext) error {
+	_, err := ctx.GetUserId()
+	if err != nil {
+		return ctx.Unauthorized(err.Error())
+	}
+
+	projectID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return ctx.BadRequest("invalid project id")
+	}
+
+	snapshotID, err := strconv.Atoi(ctx.Param("snapshotId"))
+	if err != nil {
+		return ctx.BadRequest("invalid snapshot id")
+	}
+
+	repo := NewRepository(ctx)
+	snapshot, err := repo.GetSnapshot(projectID, snapshotID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ctx.NotFound("snapshot not found")
+	}
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+
+	return ctx.Sucsess(snapshot)
+}
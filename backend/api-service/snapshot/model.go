@@ -0,0 +1,57 @@
+package snapshot
+
+import (
+	"backend/connectors"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	SourceManual   = "manual"
+	SourceVersion  = "version"
+	SourceSchedule = "schedule"
+)
+
+type Snapshot struct {
+	ID        int       `db:"id" json:"id"`
+	ProjectID int       `db:"project_id" json:"projectId"`
+	VersionID *int      `db:"version_id" json:"versionId,omitempty"`
+	Hash      string    `db:"hash" json:"hash"`
+	Source    string    `db:"source" json:"source"`
+	CreatedBy *int      `db:"created_by" json:"createdBy,omitempty"`
+	CreatedAt time.Time `db:"created_at" json:"createdAt"`
+}
+
+type SnapshotDetail struct {
+	Snapshot
+	Structure Structure `db:"structure" json:"structure"`
+}
+
+type CaptureRequest struct {
+	ProjectID int
+	VersionID *int
+	UserID    *int
+	Source    string
+}
+
+type CaptureResponse struct {
+	Snapshot     Snapshot `json:"snapshot"`
+	Deduplicated bool     `json:"deduplicated"`
+}
+
+type Structure connectors.DatabaseStructureResponse
+
+func (s Structure) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+func (s *Structure) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(b, s)
+}
@@ -0,0 +1,203 @@
+package snapshot
+
+import (
+	"backend/connectors"
+	"backend/core"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(ctx *core.WebContext) *Repository {
+	return NewRepositoryFromDb(ctx.GetDb())
+}
+
+func NewRepositoryFromDb(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// HashStructure returns the content hash used to deduplicate snapshots.
+func HashStructure(structure *connectors.DatabaseStructureResponse) (string, error) {
+	data, err := json.Marshal(structure)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Capture introspects the project's target database and stores the result.
+// The structure itself is stored once per hash. Manual and scheduled captures
+// that match the latest snapshot of the project return that snapshot instead
+// of creating a new one.
+func (r *Repository) Capture(conn connectors.DBConnector, req CaptureRequest) (*CaptureResponse, error) {
+	structure, err := conn.IntrospectDatabase(req.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Store(structure, req)
+}
+
+func (r *Repository) Store(structure *connectors.DatabaseStructureResponse, req CaptureRequest) (*CaptureResponse, error) {
+	hash, err := HashStructure(structure)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Source != SourceVersion {
+		latest, err := r.GetLatestSnapshot(req.ProjectID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		if latest != nil && latest.Hash == hash {
+			return &CaptureResponse{Snapshot: *latest, Deduplicated: true}, nil
+		}
+	}
+
+	contentStmt, err := r.db.PrepareNamed(`
+		INSERT INTO schema_snapshot_contents (hash, structure)
+		VALUES (:hash, :structure)
+		ON CONFLICT (hash) DO NOTHING`)
+	if err != nil {
+		return nil, err
+	}
+	defer contentStmt.Close()
+
+	_, err = contentStmt.Exec(map[string]any{
+		"hash":      hash,
+		"structure": Structure(*structure),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, err := r.db.PrepareNamed(`
+		INSERT INTO schema_snapshots (project_id, version_id, hash, source, created_by)
+		VALUES (:projectId, :versionId, :hash, :source, :createdBy)
+		RETURNING id, project_id, version_id, hash, source, created_by, created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var snapshot Snapshot
+	err = stmt.Get(&snapshot, map[string]any{
+		"projectId": req.ProjectID,
+		"versionId": req.VersionID,
+		"hash":      hash,
+		"source":    req.Source,
+		"createdBy": req.UserID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &CaptureResponse{Snapshot: snapshot}, nil
+}
+
+func (r *Repository) GetLatestSnapshot(projectID int) (*Snapshot, error) {
+	stmt, err := r.db.PrepareNamed(`
+		SELECT id, project_id, version_id, hash, source, created_by, created_at
+		FROM schema_snapshots
+		WHERE project_id = :projectId
+		ORDER BY id DESC
+		LIMIT 1`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var snapshot Snapshot
+	if err := stmt.Get(&snapshot, map[string]any{"projectId": projectID}); err != nil {
+		return nil, err
+	}
+
+	return &snapshot, nil
+}
+
+func (r *Repository) ListSnapshots(projectID int, limit int, offset int) ([]Snapshot, error) {
+	snapshots := []Snapshot{}
+
+	stmt, err := r.db.PrepareNamed(`
+		SELECT id, project_id, version_id, hash, source, created_by, created_at
+		FROM schema_snapshots
+		WHERE project_id = :projectId
+		ORDER BY id DESC
+		LIMIT :limit OFFSET :offset`)
+	if err != nil {
+		return snapshots, err
+	}
+	defer stmt.Close()
+
+	params := map[string]any{
+		"projectId": projectID,
+		"limit":     limit,
+		"offset":    offset,
+	}
+
+	err = stmt.Select(&snapshots, params)
+	return snapshots, err
+}
+
+func (r *Repository) GetSnapshot(projectID int, snapshotID int) (*SnapshotDetail, error) {
+	stmt, err := r.db.PrepareNamed(`
+		SELECT s.id, s.project_id, s.version_id, s.hash, s.source, s.created_by, s.created_at, c.structure
+		FROM schema_snapshots s
+		JOIN schema_snapshot_contents c ON c.hash = s.hash
+		WHERE s.project_id = :projectId AND s.id = :id`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var detail SnapshotDetail
+	if err := stmt.Get(&detail, map[string]any{"projectId": projectID, "id": snapshotID}); err != nil {
+		return nil, err
+	}
+
+	return &detail, nil
+}
+
+func (r *Repository) GetActiveProjectIDs() ([]int, error) {
+	var ids []int
+	err := r.db.Select(&ids, `
+		SELECT p.id
+		FROM projects p
+		JOIN projects_credentials pc ON pc.project_id = p.id
+		WHERE p.active = true
+		ORDER BY p.id`)
+	return ids, err
+}
+
+// GetSnapshotForVersion returns the latest snapshot recorded after the given
+// version was applied.
+func (r *Repository) GetSnapshotForVersion(projectID int, versionID int) (*SnapshotDetail, error) {
+	stmt, err := r.db.PrepareNamed(`
+		SELECT s.id, s.project_id, s.version_id, s.hash, s.source, s.created_by, s.created_at, c.structure
+		FROM schema_snapshots s
+		JOIN schema_snapshot_contents c ON c.hash = s.hash
+		WHERE s.project_id = :projectId AND s.version_id = :versionId
+		ORDER BY s.id DESC
+		LIMIT 1`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var detail SnapshotDetail
+	if err := stmt.Get(&detail, map[string]any{"projectId": projectID, "versionId": versionID}); err != nil {
+		return nil, err
+	}
+
+	return &detail, nil
+}
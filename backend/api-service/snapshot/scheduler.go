@@ -0,0 +1,52 @@
+package snapshot
+
+import (
+	"backend/core"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+)
+
+// StartScheduler captures a snapshot of every active project at the given
+// interval. A zero interval disables scheduled snapshots.
+func StartScheduler(db *sqlx.DB, interval time.Duration, logger echo.Logger) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			captureActiveProjects(db, logger)
+		}
+	}()
+}
+
+func captureActiveProjects(db *sqlx.DB, logger echo.Logger) {
+	repo := NewRepositoryFromDb(db)
+
+	projectIDs, err := repo.GetActiveProjectIDs()
+	if err != nil {
+		logger.Errorf("scheduled snapshot: failed to load projects: %v", err)
+		return
+	}
+
+	for _, projectID := range projectIDs {
+		conn, err := core.NewConnector(db, projectID)
+		if err != nil {
+			logger.Errorf("scheduled snapshot: project %d: %v", projectID, err)
+			continue
+		}
+
+		_, err = repo.Capture(conn, CaptureRequest{
+			ProjectID: projectID,
+			Source:    SourceSchedule,
+		})
+		if err != nil {
+			logger.Errorf("scheduled snapshot: project %d: %v", projectID, err)
+		}
+	}
+}
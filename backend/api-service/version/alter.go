@@ -0,0 +1,326 @@
+package version
+
+import (
+	"backend/connectors"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Actions of an AlterTableOperation.
+const (
+	AlterAddColumn    = "add_column"
+	AlterDropColumn   = "drop_column"
+	AlterRenameColumn = "rename_column"
+	AlterColumnType   = "alter_column_type"
+	AlterSetNullable  = "set_nullable"
+	AlterSetDefault   = "set_default"
+	AlterDropDefault  = "drop_default"
+	AlterRenameTable  = "rename_table"
+)
+
+var (
+	ErrInvalidOperation  = errors.New("invalid operation")
+	ErrDestructiveChange = errors.New("destructive changes require confirmDestructive")
+)
+
+// TableChange holds the statements generated for a structured request. Down
+// statements are already in the order they have to run.
+type TableChange struct {
+	Up          []string
+	Down        []string
+	Destructive []string
+	Warnings    []string
+}
+
+// alterBuilder applies operations to a copy of the live table, so each
+// operation sees the result of the previous ones and the down script can
+// restore the definitions it replaced.
+type alterBuilder struct {
+	gen    connectors.SchemaChangeGenerator
+	table  connectors.TableStructureResponse
+	change TableChange
+	down   [][]string
+}
+
+func BuildAlterTable(gen connectors.SchemaChangeGenerator, table connectors.TableStructureResponse, operations []AlterTableOperation) (*TableChange, error) {
+	if len(operations) == 0 {
+		return nil, fmt.Errorf("%w: no operations provided", ErrInvalidOperation)
+	}
+
+	b := &alterBuilder{gen: gen, table: cloneTable(table)}
+	for i, op := range operations {
+		if err := b.apply(op); err != nil {
+			return nil, fmt.Errorf("operation %d (%s): %w", i+1, op.Action, err)
+		}
+	}
+
+	for i := len(b.down) - 1; i >= 0; i-- {
+		b.change.Down = append(b.change.Down, b.down[i]...)
+	}
+	return &b.change, nil
+}
+
+func BuildDropTable(gen connectors.SchemaChangeGenerator, table connectors.TableStructureResponse) *TableChange {
+	return &TableChange{
+		Up:          []string{gen.DropTableStatement(table.SchemaName, table.TableName)},
+		Down:        []string{gen.CreateTableStatement(table)},
+		Destructive: []string{"drop_table " + table.TableName},
+		Warnings:    []string{"the down script restores the table definition but not its data"},
+	}
+}
+
+func (b *alterBuilder) apply(op AlterTableOperation) error {
+	schema, name := b.table.SchemaName, b.table.TableName
+
+	switch op.Action {
+	case AlterAddColumn:
+		if op.Definition == nil || op.Definition.Type == "" {
+			return fmt.Errorf("%w: definition with name and type is required", ErrInvalidOperation)
+		}
+		if op.Definition.PrimaryKey {
+			return fmt.Errorf("%w: add primary keys with a constraint request", ErrInvalidOperation)
+		}
+		if b.column(op.Definition.Name) >= 0 {
+			return fmt.Errorf("%w: column %s already exists", ErrInvalidOperation, op.Definition.Name)
+		}
+
+		col, warnings, err := columnFromRequest(b.gen, *op.Definition)
+		if err != nil {
+			return err
+		}
+		b.change.Warnings = append(b.change.Warnings, warnings...)
+		if !col.Nullable && col.DefaultValue == nil {
+			b.change.Warnings = append(b.change.Warnings, fmt.Sprintf(
+				"%s is NOT NULL without a default, this fails when the table has rows", col.ColumnName))
+		}
+		b.emit([]string{b.gen.AddColumnStatement(schema, name, col)},
+			[]string{b.gen.DropColumnStatement(schema, name, col.ColumnName)})
+		b.table.Columns = append(b.table.Columns, col)
+
+	case AlterDropColumn:
+		i, err := b.existing(op.Column)
+		if err != nil {
+			return err
+		}
+		col := b.table.Columns[i]
+
+		// Dependent indexes and constraints are dropped explicitly, not every
+		// engine does it implicitly, and the down script restores them.
+		var up, down []string
+		var constraints []connectors.ConstraintStructureResponse
+		for _, c := range b.table.Constraints {
+			if containsName(c.Columns, col.ColumnName) {
+				up = append(up, b.gen.DropConstraintStatement(schema, name, c))
+				continue
+			}
+			constraints = append(constraints, c)
+		}
+		var indexes []connectors.IndexStructureResponse
+		for _, index := range b.table.Indexes {
+			if containsName(index.Columns, col.ColumnName) && !index.Primary && !b.constraintIndex(index.IndexName) {
+				up = append(up, b.gen.DropIndexStatement(schema, name, index))
+				down = append(down, b.gen.CreateIndexStatement(schema, name, index))
+				continue
+			}
+			if !containsName(index.Columns, col.ColumnName) {
+				indexes = append(indexes, index)
+			}
+		}
+		up = append(up, b.gen.DropColumnStatement(schema, name, col.ColumnName))
+		down = append([]string{b.gen.AddColumnStatement(schema, name, col)}, down...)
+		for _, c := range b.table.Constraints {
+			if containsName(c.Columns, col.ColumnName) {
+				down = append(down, b.gen.AddConstraintStatement(schema, name, c))
+			}
+		}
+
+		b.emit(up, down)
+		b.change.Destructive = append(b.change.Destructive, "drop_column "+col.ColumnName)
+		b.change.Warnings = append(b.change.Warnings, fmt.Sprintf(
+			"the down script restores %s but not its data", col.ColumnName))
+		b.table.Columns = append(b.table.Columns[:i:i], b.table.Columns[i+1:]...)
+		b.table.Constraints = constraints
+		b.table.Indexes = indexes
+
+	case AlterRenameColumn:
+		i, err := b.existing(op.Column)
+		if err != nil {
+			return err
+		}
+		if err := validateName("column", op.NewName); err != nil {
+			return err
+		}
+		if b.column(op.NewName) >= 0 {
+			return fmt.Errorf("%w: column %s already exists", ErrInvalidOperation, op.NewName)
+		}
+
+		old := b.table.Columns[i].ColumnName
+		b.emit([]string{b.gen.RenameColumnStatement(schema, name, old, op.NewName)},
+			[]string{b.gen.RenameColumnStatement(schema, name, op.NewName, old)})
+		b.change.Warnings = append(b.change.Warnings, fmt.Sprintf(
+			"renaming %s to %s breaks code that still uses the old name", old, op.NewName))
+		b.renameColumn(old, op.NewName)
+
+	case AlterColumnType, AlterSetNullable, AlterSetDefault, AlterDropDefault:
+		i, err := b.existing(op.Column)
+		if err != nil {
+			return err
+		}
+		from := b.table.Columns[i]
+		to := from
+
+		switch op.Action {
+		case AlterColumnType:
+			if op.Type == "" {
+				return fmt.Errorf("%w: type is required", ErrInvalidOperation)
+			}
+			t, err := connectors.ParseLogicalType(op.Type)
+			if err != nil {
+				return err
+			}
+			to.DataType, to.ColumnType = b.gen.NativeType(t), ""
+			b.change.Destructive = append(b.change.Destructive, "alter_column_type "+from.ColumnName)
+		case AlterSetNullable:
+			if op.Nullable == nil {
+				return fmt.Errorf("%w: nullable is required", ErrInvalidOperation)
+			}
+			to.Nullable = *op.Nullable
+		case AlterSetDefault:
+			if op.Default == "" {
+				return fmt.Errorf("%w: default is required", ErrInvalidOperation)
+			}
+			t := connectors.LogicalType{Name: connectors.TypeNative, Native: columnTypeOf(from)}
+			if op.Type != "" {
+				if t, err = connectors.ParseLogicalType(op.Type); err != nil {
+					return err
+				}
+			}
+			value, err := b.gen.DefaultValue(t, op.Default)
+			if err != nil {
+				return err
+			}
+			to.DefaultValue = &value
+		case AlterDropDefault:
+			if from.DefaultValue == nil {
+				return fmt.Errorf("%w: column %s has no default", ErrInvalidOperation, from.ColumnName)
+			}
+			to.DefaultValue = nil
+		}
+
+		up, err := b.gen.AlterColumnStatements(schema, name, from, to)
+		if err != nil {
+			return err
+		}
+		down, err := b.gen.AlterColumnStatements(schema, name, to, from)
+		if err != nil {
+			return err
+		}
+		if len(up) == 0 {
+			return fmt.Errorf("%w: column %s already has this definition", ErrInvalidOperation, from.ColumnName)
+		}
+		b.emit(up, down)
+		b.table.Columns[i] = to
+
+	case AlterRenameTable:
+		if err := validateName("table", op.NewName); err != nil {
+			return err
+		}
+		b.emit([]string{b.gen.RenameTableStatement(schema, name, op.NewName)},
+			[]string{b.gen.RenameTableStatement(schema, op.NewName, name)})
+		b.change.Warnings = append(b.change.Warnings, fmt.Sprintf(
+			"renaming %s to %s breaks code that still uses the old name", name, op.NewName))
+		b.table.TableName = op.NewName
+
+	default:
+		return fmt.Errorf("%w: unknown action %q", ErrInvalidOperation, op.Action)
+	}
+
+	return nil
+}
+
+func (b *alterBuilder) emit(up []string, down []string) {
+	b.change.Up = append(b.change.Up, up...)
+	b.down = append(b.down, down)
+}
+
+func (b *alterBuilder) column(name string) int {
+	for i, col := range b.table.Columns {
+		if strings.EqualFold(col.ColumnName, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (b *alterBuilder) existing(name string) (int, error) {
+	if name == "" {
+		return -1, fmt.Errorf("%w: column is required", ErrInvalidOperation)
+	}
+	i := b.column(name)
+	if i < 0 {
+		return -1, fmt.Errorf("%w: column %s does not exist in %s", ErrInvalidOperation, name, b.table.TableName)
+	}
+	return i, nil
+}
+
+func (b *alterBuilder) constraintIndex(indexName string) bool {
+	for _, c := range b.table.Constraints {
+		if c.ConstraintName == indexName {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *alterBuilder) renameColumn(old string, name string) {
+	b.table.Columns[b.column(old)].ColumnName = name
+	for i := range b.table.Indexes {
+		renameInList(b.table.Indexes[i].Columns, old, name)
+	}
+	for i := range b.table.Constraints {
+		renameInList(b.table.Constraints[i].Columns, old, name)
+	}
+}
+
+func renameInList(names []string, old string, name string) {
+	for i := range names {
+		if names[i] == old {
+			names[i] = name
+		}
+	}
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// cloneTable copies a table deep enough that the builder can change it without
+// touching the caller's slices.
+func cloneTable(table connectors.TableStructureResponse) connectors.TableStructureResponse {
+	clone := table
+	clone.Columns = append([]connectors.ColumnStructureResponse{}, table.Columns...)
+	clone.Indexes = make([]connectors.IndexStructureResponse, len(table.Indexes))
+	for i, index := range table.Indexes {
+		index.Columns = append([]string{}, index.Columns...)
+		clone.Indexes[i] = index
+	}
+	clone.Constraints = make([]connectors.ConstraintStructureResponse, len(table.Constraints))
+	for i, c := range table.Constraints {
+		c.Columns = append([]string{}, c.Columns...)
+		clone.Constraints[i] = c
+	}
+	return clone
+}
+
+func columnTypeOf(col connectors.ColumnStructureResponse) string {
+	if col.ColumnType != "" {
+		return col.ColumnType
+	}
+	return col.DataType
+}
@@ -0,0 +1,201 @@
+package version
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxArchiveSize limits uploaded migration archives and the decompressed size
+// of all files in them together. MaxArchiveFiles limits their entries.
+const (
+	MaxArchiveSize  = 32 << 20
+	MaxArchiveFiles = 10000
+)
+
+var ErrInvalidArchive = errors.New("invalid archive")
+
+type archiveFile struct {
+	Path    string
+	Content []byte
+}
+
+// archiveBudget is what is left of the limits while an archive is read, so a
+// compressed archive cannot expand beyond them.
+type archiveBudget struct {
+	bytes   int64
+	entries int
+}
+
+func newArchiveBudget() *archiveBudget {
+	return &archiveBudget{bytes: MaxArchiveSize, entries: MaxArchiveFiles}
+}
+
+// entry counts one entry of the archive, including skipped ones.
+func (b *archiveBudget) entry() error {
+	if b.entries--; b.entries < 0 {
+		return fmt.Errorf("archive has more than %d entries", MaxArchiveFiles)
+	}
+	return nil
+}
+
+// read reads a file and charges its size.
+func (b *archiveBudget) read(r io.Reader) ([]byte, error) {
+	content, err := io.ReadAll(io.LimitReader(r, b.bytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if b.bytes -= int64(len(content)); b.bytes < 0 {
+		return nil, fmt.Errorf("archive expands to more than %d bytes", MaxArchiveSize)
+	}
+	return content, nil
+}
+
+// readArchive returns the regular files of a zip, tar or gzipped tar archive
+// sorted by path. The format is detected from the content, not a file name.
+func readArchive(data []byte) ([]archiveFile, error) {
+	var files []archiveFile
+	var err error
+
+	switch {
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")), bytes.HasPrefix(data, []byte("PK\x05\x06")):
+		files, err = readZip(data, newArchiveBudget())
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		var gz *gzip.Reader
+		gz, err = gzip.NewReader(bytes.NewReader(data))
+		if err == nil {
+			files, err = readTar(gz, newArchiveBudget())
+		}
+	case len(data) > 262 && string(data[257:262]) == "ustar":
+		files, err = readTar(bytes.NewReader(data), newArchiveBudget())
+	default:
+		return nil, fmt.Errorf("%w: expected a zip, tar or tar.gz file", ErrInvalidArchive)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+func readZip(data []byte, budget *archiveBudget) ([]archiveFile, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	var files []archiveFile
+	for _, f := range reader.File {
+		if err := budget.entry(); err != nil {
+			return nil, err
+		}
+		if f.FileInfo().IsDir() || ignoredArchivePath(f.Name) {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		content, err := budget.read(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		files = append(files, archiveFile{Path: path.Clean(f.Name), Content: content})
+	}
+	return files, nil
+}
+
+func readTar(r io.Reader, budget *archiveBudget) ([]archiveFile, error) {
+	reader := tar.NewReader(r)
+
+	var files []archiveFile
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := budget.entry(); err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg || ignoredArchivePath(header.Name) {
+			continue
+		}
+		content, err := budget.read(reader)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", header.Name, err)
+		}
+		files = append(files, archiveFile{Path: path.Clean(header.Name), Content: content})
+	}
+}
+
+// ignoredArchivePath skips metadata that archivers add, like the __MACOSX
+// folder and dot files.
+func ignoredArchivePath(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if part == "__MACOSX" || strings.HasPrefix(part, ".") && part != "." && part != ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// writeZip packs files into a zip archive in the given order.
+func writeZip(files []archiveFile, modified time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for _, f := range files {
+		w, err := writer.CreateHeader(&zip.FileHeader{Name: f.Path, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(f.Content); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeTarGz packs files into a gzipped tar archive in the given order.
+func writeTarGz(files []archiveFile, modified time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	writer := tar.NewWriter(gz)
+	for _, f := range files {
+		header := &tar.Header{
+			Name:     f.Path,
+			Mode:     0o644,
+			Size:     int64(len(f.Content)),
+			ModTime:  modified,
+			Typeflag: tar.TypeReg,
+		}
+		if err := writer.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		if _, err := writer.Write(f.Content); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
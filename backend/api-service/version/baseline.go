@@ -0,0 +1,159 @@
+package version
+
+import (
+	"backend/connectors"
+	"backend/schemaDiff"
+	"backend/snapshot"
+	"context"
+	"errors"
+	"fmt"
+)
+
+var ErrAlreadyApplied = errors.New("project already has applied versions")
+
+// defaultSchemas exist on a new database of each engine, the baseline script
+// does not create them.
+var defaultSchemas = map[string]string{
+	connectors.DialectPostgres: "public",
+	connectors.DialectMSSQL:    "dbo",
+}
+
+// Baseline records the existing schema of the target as the first version of
+// a project. The generated creation script is marked completed without being
+// executed, so it only runs on new environments, and the introspected
+// structure is stored as the snapshot of that version. The script holds the
+// tables and sequences, other objects are reported in the warnings.
+func (m *Migrator) Baseline(req BaselineRequest) (*BaselineResponse, error) {
+	ctx := context.Background()
+	db, target, err := m.openTarget(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	defer target.Close()
+
+	unlock, err := m.lock(ctx, target, operationBaseline)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if _, err := m.reconcile(ctx, target); err != nil {
+		return nil, fmt.Errorf("failed to reconcile with %s: %w", connectors.HistoryTableName, err)
+	}
+
+	latest, _, err := m.repo.GetLatestVersion(m.projectID, true)
+	if err != nil {
+		return nil, err
+	}
+	if latest != "" {
+		return nil, fmt.Errorf("%w: %s is already completed, a baseline must be the first version", ErrAlreadyApplied, latest)
+	}
+
+	live, err := m.conn.IntrospectDatabase(m.projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to introspect target database: %w", err)
+	}
+	// The snapshot is what later versions and drift checks compare against,
+	// so it holds the same tables as the baseline script.
+	structure := connectors.WithoutHistoryTable(live)
+
+	script := schemaDiff.GenerateMigration(m.conn, baselineStart(m.conn.Dialect()), structure)
+	if len(script.Statements) == 0 {
+		return nil, fmt.Errorf("%w: the target database has no tables to baseline", ErrInvalidOperation)
+	}
+
+	objects, err := m.conn.ListObjects(m.projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects of target database: %w", err)
+	}
+	// Sequences are created ahead of the tables, serial columns default to
+	// them. Other objects are not part of snapshots and are left out.
+	var sequences []string
+	warnings := append([]string{}, script.Warnings...)
+	for _, object := range objects {
+		if object.ObjectType != connectors.ObjectTypeSequence {
+			warnings = append(warnings, fmt.Sprintf("%s %s.%s is not part of the baseline, add it in a version",
+				object.ObjectType, object.SchemaName, object.ObjectName))
+			continue
+		}
+		ddl, err := m.conn.GetObjectDDL(m.projectID, connectors.ObjectDDLRequest{
+			ObjectType: object.ObjectType,
+			SchemaName: object.SchemaName,
+			ObjectName: object.ObjectName,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sequence %s.%s: %w", object.SchemaName, object.ObjectName, err)
+		}
+		sequences = append(sequences, ddl.Statement)
+	}
+
+	response := &BaselineResponse{
+		Up:       m.conn.JoinStatements(append(sequences, script.Statements...)),
+		Warnings: warnings,
+	}
+	for _, schema := range structure.Schemas {
+		response.Tables += len(schema.Tables)
+	}
+	if req.Preview {
+		return response, nil
+	}
+
+	label := req.Version
+	if label == "" {
+		scheme, err := m.repo.GetVersionScheme(m.projectID)
+		if err != nil {
+			return nil, err
+		}
+		label = baselineLabel(scheme)
+	}
+
+	version, err := m.repo.InsertVersion(NewVersion{
+		ProjectID:   m.projectID,
+		Version:     label,
+		Up:          NewScript(response.Up),
+		AuthorID:    m.userID,
+		Description: "baseline of the existing schema",
+		// The schema already exists, the script only documents it.
+		AcceptLintErrors: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := m.markApplied(ctx, target, version, "baseline of the existing schema, not executed"); err != nil {
+		return nil, err
+	}
+	response.Version = &VersionRef{VersionID: version.Id, Version: version.Version}
+
+	versionRef, userRef := version.Id, m.userID
+	captured, err := m.snapshots.Store(structure, snapshot.CaptureRequest{
+		ProjectID: m.projectID,
+		VersionID: &versionRef,
+		UserID:    &userRef,
+		Source:    snapshot.SourceVersion,
+	})
+	if err != nil {
+		response.Warnings = append(response.Warnings, "failed to store schema snapshot: "+err.Error())
+	} else {
+		response.SnapshotID = &captured.Snapshot.ID
+	}
+
+	return response, nil
+}
+
+// baselineLabel is the lowest label of a scheme, so the baseline sorts before
+// versions that were created before it.
+func baselineLabel(scheme string) string {
+	if scheme == SchemeTimestamp {
+		return "19700101000000"
+	}
+	return "v0.0.0"
+}
+
+func baselineStart(dialect string) *connectors.DatabaseStructureResponse {
+	start := &connectors.DatabaseStructureResponse{Schemas: []connectors.SchemaStructureResponse{}}
+	if schema, ok := defaultSchemas[dialect]; ok {
+		start.Schemas = append(start.Schemas, connectors.SchemaStructureResponse{SchemaName: schema})
+	}
+	return start
+}
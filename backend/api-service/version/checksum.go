@@ -0,0 +1,58 @@
+package version
+
+import "fmt"
+
+// ValidateChecksums compares the stored scripts of every version with the
+// checksums recorded when it was created and, for completed versions, when its
+// up script was applied. Versions created before checksums existed are skipped,
+// and so is the applied checksum of repeatable versions, which are meant to
+// change after they ran.
+func ValidateChecksums(versions []Version) []ChecksumIssue {
+	issues := []ChecksumIssue{}
+
+	check := func(v Version, script string, expected *string, actual string, reason string) {
+		if expected == nil || *expected == "" || *expected == actual {
+			return
+		}
+		issues = append(issues, ChecksumIssue{
+			VersionID: v.Id,
+			Version:   v.Version,
+			State:     v.State,
+			Script:    script,
+			Expected:  *expected,
+			Actual:    actual,
+			Reason:    reason,
+		})
+	}
+
+	for _, v := range versions {
+		up, down := v.Up.Checksum(), v.Down.Checksum()
+
+		if v.State == StateCompleted && v.Kind != KindRepeatable {
+			check(v, "up", v.AppliedChecksum, up, "up script changed after it was applied")
+		}
+		check(v, "up", v.UpChecksum, up, "up script changed since the version was created")
+		check(v, "down", v.DownChecksum, down, "down script changed since the version was created")
+	}
+
+	return issues
+}
+
+func (r *Repository) Validate(projectID int) (*ValidationResponse, error) {
+	versions, err := r.GetVersions(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	issues := ValidateChecksums(versions)
+	return &ValidationResponse{
+		ProjectID: projectID,
+		Valid:     len(issues) == 0,
+		Issues:    issues,
+	}, nil
+}
+
+func validationError(issues []ChecksumIssue) error {
+	return fmt.Errorf("%w: %d checksum mismatches, first: version %d (%s) %s",
+		ErrValidationFailed, len(issues), issues[0].VersionID, issues[0].Version, issues[0].Reason)
+}
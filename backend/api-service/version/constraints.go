@@ -0,0 +1,312 @@
+package version
+
+import (
+	"backend/connectors"
+	"fmt"
+	"strings"
+)
+
+// Actions of index and constraint requests.
+const (
+	ActionCreate = "create"
+	ActionDrop   = "drop"
+)
+
+// Constraint types accepted by ConstraintRequest.
+const (
+	ConstraintForeignKey = "foreign_key"
+	ConstraintUnique     = "unique"
+	ConstraintCheck      = "check"
+)
+
+var constraintTypes = map[string]string{
+	ConstraintForeignKey: "FOREIGN KEY",
+	ConstraintUnique:     "UNIQUE",
+	ConstraintCheck:      "CHECK",
+}
+
+var referentialActions = map[string]bool{
+	"CASCADE":     true,
+	"SET NULL":    true,
+	"SET DEFAULT": true,
+	"RESTRICT":    true,
+	"NO ACTION":   true,
+}
+
+// maxIdentifierLength is the shortest identifier limit of the supported
+// engines (PostgreSQL), generated names are cut to it.
+const maxIdentifierLength = 63
+
+func BuildIndexChange(gen connectors.SchemaChangeGenerator, table connectors.TableStructureResponse, req IndexRequest) (*TableChange, error) {
+	schema, name := table.SchemaName, table.TableName
+
+	switch req.Action {
+	case ActionCreate:
+		if len(req.Columns) == 0 {
+			return nil, fmt.Errorf("%w: columns are required", ErrInvalidOperation)
+		}
+		if err := requireColumns(table, req.Columns); err != nil {
+			return nil, err
+		}
+		if req.IndexName != "" {
+			if err := validateName("index", req.IndexName); err != nil {
+				return nil, err
+			}
+		}
+		if err := validateExpression("where", req.Where); err != nil {
+			return nil, err
+		}
+		if req.Where != "" && gen.Dialect() == connectors.DialectMySQL {
+			return nil, fmt.Errorf("%w: MySQL does not support partial indexes", ErrInvalidOperation)
+		}
+		if req.Concurrently && gen.Dialect() != connectors.DialectPostgres {
+			return nil, fmt.Errorf("%w: concurrent index builds are only supported on PostgreSQL", ErrInvalidOperation)
+		}
+
+		index := connectors.IndexStructureResponse{
+			IndexName: req.IndexName,
+			Columns:   req.Columns,
+			Unique:    req.Unique,
+			Predicate: req.Where,
+		}
+		if index.IndexName == "" {
+			suffix := "idx"
+			if req.Unique {
+				suffix = "key"
+			}
+			index.IndexName = generatedName(name, req.Columns, suffix)
+		}
+		if hasIndex(table, index.IndexName) {
+			return nil, fmt.Errorf("%w: index %s already exists on %s", ErrInvalidOperation, index.IndexName, name)
+		}
+
+		change := &TableChange{
+			Up:   []string{concurrently(gen.CreateIndexStatement(schema, name, index), req.Concurrently)},
+			Down: []string{concurrently(gen.DropIndexStatement(schema, name, index), req.Concurrently)},
+		}
+		if req.Concurrently {
+			change.Warnings = append(change.Warnings, "concurrent index builds run outside a transaction")
+		} else if gen.Dialect() == connectors.DialectPostgres {
+			change.Warnings = append(change.Warnings, "the index build blocks writes to the table, consider concurrently")
+		}
+		return change, nil
+
+	case ActionDrop:
+		index, ok := findIndex(table, req.IndexName)
+		if !ok {
+			return nil, fmt.Errorf("%w: index %s does not exist on %s", ErrInvalidOperation, req.IndexName, name)
+		}
+		if index.Primary || isConstraintIndex(table, index.IndexName) {
+			return nil, fmt.Errorf("%w: index %s belongs to a constraint, drop the constraint instead", ErrInvalidOperation, index.IndexName)
+		}
+		if req.Concurrently && gen.Dialect() != connectors.DialectPostgres {
+			return nil, fmt.Errorf("%w: concurrent index drops are only supported on PostgreSQL", ErrInvalidOperation)
+		}
+
+		return &TableChange{
+			Up:   []string{concurrently(gen.DropIndexStatement(schema, name, index), req.Concurrently)},
+			Down: []string{concurrently(gen.CreateIndexStatement(schema, name, index), req.Concurrently)},
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w: unknown action %q", ErrInvalidOperation, req.Action)
+}
+
+// BuildConstraintChange generates a foreign key, unique or check constraint
+// change. referenced is the live referenced table of a foreign key.
+func BuildConstraintChange(gen connectors.SchemaChangeGenerator, table connectors.TableStructureResponse, referenced *connectors.TableStructureResponse, req ConstraintRequest) (*TableChange, error) {
+	schema, name := table.SchemaName, table.TableName
+
+	switch req.Action {
+	case ActionCreate:
+		constraintType, ok := constraintTypes[req.Type]
+		if !ok {
+			return nil, fmt.Errorf("%w: type must be foreign_key, unique or check", ErrInvalidOperation)
+		}
+
+		if req.ConstraintName != "" {
+			if err := validateName("constraint", req.ConstraintName); err != nil {
+				return nil, err
+			}
+		}
+
+		constraint := connectors.ConstraintStructureResponse{
+			ConstraintName: req.ConstraintName,
+			ConstraintType: constraintType,
+			Columns:        req.Columns,
+		}
+
+		switch req.Type {
+		case ConstraintForeignKey, ConstraintUnique:
+			if len(req.Columns) == 0 {
+				return nil, fmt.Errorf("%w: columns are required", ErrInvalidOperation)
+			}
+			if err := requireColumns(table, req.Columns); err != nil {
+				return nil, err
+			}
+		case ConstraintCheck:
+			if strings.TrimSpace(req.Check) == "" {
+				return nil, fmt.Errorf("%w: check expression is required", ErrInvalidOperation)
+			}
+			if err := validateExpression("check", req.Check); err != nil {
+				return nil, err
+			}
+			constraint.Definition = req.Check
+		}
+
+		if req.Type == ConstraintForeignKey {
+			if err := validateForeignKey(gen, table, referenced, &req); err != nil {
+				return nil, err
+			}
+			constraint.ReferencedSchema = referenced.SchemaName
+			constraint.ReferencedTable = referenced.TableName
+			constraint.ReferencedColumns = req.ReferencedColumns
+			constraint.OnDelete = req.OnDelete
+			constraint.OnUpdate = req.OnUpdate
+		}
+
+		if constraint.ConstraintName == "" {
+			suffix := map[string]string{ConstraintForeignKey: "fkey", ConstraintUnique: "key", ConstraintCheck: "check"}[req.Type]
+			constraint.ConstraintName = generatedName(name, req.Columns, suffix)
+		}
+		if findConstraint(table, constraint.ConstraintName) != nil {
+			return nil, fmt.Errorf("%w: constraint %s already exists on %s", ErrInvalidOperation, constraint.ConstraintName, name)
+		}
+
+		change := &TableChange{
+			Up:   []string{gen.AddConstraintStatement(schema, name, constraint)},
+			Down: []string{gen.DropConstraintStatement(schema, name, constraint)},
+		}
+		if req.Type != ConstraintUnique {
+			change.Warnings = append(change.Warnings, "existing rows are validated while the table is locked")
+		}
+		return change, nil
+
+	case ActionDrop:
+		constraint := findConstraint(table, req.ConstraintName)
+		if constraint == nil {
+			return nil, fmt.Errorf("%w: constraint %s does not exist on %s", ErrInvalidOperation, req.ConstraintName, name)
+		}
+
+		return &TableChange{
+			Up:   []string{gen.DropConstraintStatement(schema, name, *constraint)},
+			Down: []string{gen.AddConstraintStatement(schema, name, *constraint)},
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w: unknown action %q", ErrInvalidOperation, req.Action)
+}
+
+func validateForeignKey(gen connectors.SchemaChangeGenerator, table connectors.TableStructureResponse, referenced *connectors.TableStructureResponse, req *ConstraintRequest) error {
+	if referenced == nil {
+		return fmt.Errorf("%w: referenced table %s does not exist", ErrInvalidOperation, req.ReferencedTable)
+	}
+	if len(req.ReferencedColumns) != len(req.Columns) {
+		return fmt.Errorf("%w: columns and referencedColumns must have the same length", ErrInvalidOperation)
+	}
+	if err := requireColumns(*referenced, req.ReferencedColumns); err != nil {
+		return err
+	}
+
+	// The referenced columns must be covered by a primary key or unique
+	// constraint, every engine rejects the foreign key otherwise.
+	covered := false
+	for _, c := range referenced.Constraints {
+		if (c.ConstraintType == "PRIMARY KEY" || c.ConstraintType == "UNIQUE") && sameNames(c.Columns, req.ReferencedColumns) {
+			covered = true
+		}
+	}
+	for _, index := range referenced.Indexes {
+		if (index.Primary || index.Unique) && index.Predicate == "" && sameNames(index.Columns, req.ReferencedColumns) {
+			covered = true
+		}
+	}
+	if !covered {
+		return fmt.Errorf("%w: referenced columns of %s are not a primary key or unique", ErrInvalidOperation, referenced.TableName)
+	}
+
+	for i, action := range []*string{&req.OnDelete, &req.OnUpdate} {
+		*action = strings.ToUpper(strings.TrimSpace(*action))
+		if *action == "" {
+			continue
+		}
+		if !referentialActions[*action] || (*action == "RESTRICT" && gen.Dialect() == connectors.DialectMSSQL) {
+			field := []string{"onDelete", "onUpdate"}[i]
+			return fmt.Errorf("%w: %s %q is not supported", ErrInvalidOperation, field, *action)
+		}
+	}
+
+	return nil
+}
+
+// concurrently turns a PostgreSQL CREATE or DROP INDEX into its concurrent
+// form.
+func concurrently(statement string, enabled bool) string {
+	if !enabled {
+		return statement
+	}
+	return strings.Replace(statement, "INDEX ", "INDEX CONCURRENTLY ", 1)
+}
+
+func requireColumns(table connectors.TableStructureResponse, columns []string) error {
+	for _, name := range columns {
+		found := false
+		for _, col := range table.Columns {
+			if col.ColumnName == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w: column %s does not exist in %s", ErrInvalidOperation, name, table.TableName)
+		}
+	}
+	return nil
+}
+
+func generatedName(table string, columns []string, suffix string) string {
+	name := strings.Join(append(append([]string{table}, columns...), suffix), "_")
+	if len(name) > maxIdentifierLength {
+		name = name[:maxIdentifierLength]
+	}
+	return name
+}
+
+func findIndex(table connectors.TableStructureResponse, name string) (connectors.IndexStructureResponse, bool) {
+	for _, index := range table.Indexes {
+		if index.IndexName == name {
+			return index, true
+		}
+	}
+	return connectors.IndexStructureResponse{}, false
+}
+
+func hasIndex(table connectors.TableStructureResponse, name string) bool {
+	_, ok := findIndex(table, name)
+	return ok
+}
+
+func isConstraintIndex(table connectors.TableStructureResponse, name string) bool {
+	return findConstraint(table, name) != nil
+}
+
+func findConstraint(table connectors.TableStructureResponse, name string) *connectors.ConstraintStructureResponse {
+	for i, c := range table.Constraints {
+		if c.ConstraintName == name {
+			return &table.Constraints[i]
+		}
+	}
+	return nil
+}
+
+func sameNames(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,152 @@
+package version
+
+import (
+	"backend/connectors"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// validateName checks a name supplied by a request. Names are quoted in the
+// generated DDL, the restriction keeps them portable between engines and
+// usable without quotes in hand written SQL.
+func validateName(kind string, name string) error {
+	if name == "" {
+		return fmt.Errorf("%w: %s name is required", ErrInvalidOperation, kind)
+	}
+	if len(name) > maxIdentifierLength {
+		return fmt.Errorf("%w: %s name %s is longer than %d characters", ErrInvalidOperation, kind, name, maxIdentifierLength)
+	}
+	if !identifierPattern.MatchString(name) {
+		return fmt.Errorf("%w: %s name %q may only contain letters, digits and underscores and must not start with a digit", ErrInvalidOperation, kind, name)
+	}
+	return nil
+}
+
+// validateExpression rejects SQL fragments that could end the statement they
+// are embedded in, like index predicates and check expressions.
+func validateExpression(kind string, expression string) error {
+	if strings.Contains(expression, ";") || strings.Contains(expression, "--") || strings.Contains(expression, "/*") {
+		return fmt.Errorf("%w: %s must be a single expression without semicolons or comments", ErrInvalidOperation, kind)
+	}
+	return nil
+}
+
+// BuildCreateTable renders a CreateTableRequest in the dialect of gen. Column
+// types are logical types, see connectors.ParseLogicalType.
+func BuildCreateTable(gen connectors.SchemaChangeGenerator, req CreateTableRequest) (*TableChange, error) {
+	if err := validateName("table", req.TableName); err != nil {
+		return nil, err
+	}
+	if req.SchemaName != "" {
+		if err := validateName("schema", req.SchemaName); err != nil {
+			return nil, err
+		}
+	}
+	if len(req.Columns) == 0 {
+		return nil, fmt.Errorf("%w: no columns provided", ErrInvalidOperation)
+	}
+
+	table := connectors.TableStructureResponse{
+		SchemaName: req.SchemaName,
+		TableName:  req.TableName,
+	}
+	change := &TableChange{}
+
+	var primaryKey []string
+	for _, c := range req.Columns {
+		if containsFold(table.Columns, c.Name) {
+			return nil, fmt.Errorf("%w: column %s is defined twice", ErrInvalidOperation, c.Name)
+		}
+
+		col, warnings, err := columnFromRequest(gen, c)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", c.Name, err)
+		}
+		if c.PrimaryKey {
+			if col.Nullable {
+				return nil, fmt.Errorf("%w: primary key column %s cannot be nullable", ErrInvalidOperation, c.Name)
+			}
+			primaryKey = append(primaryKey, col.ColumnName)
+		}
+		table.Columns = append(table.Columns, col)
+		change.Warnings = append(change.Warnings, warnings...)
+	}
+
+	if len(primaryKey) > 0 {
+		table.Constraints = append(table.Constraints, connectors.ConstraintStructureResponse{
+			ConstraintName: generatedName(req.TableName, nil, "pkey"),
+			ConstraintType: "PRIMARY KEY",
+			Columns:        primaryKey,
+		})
+	} else {
+		change.Warnings = append(change.Warnings, fmt.Sprintf("%s has no primary key", req.TableName))
+	}
+
+	change.Up = []string{gen.CreateTableStatement(table)}
+	change.Down = []string{gen.DropTableStatement(req.SchemaName, req.TableName)}
+	return change, nil
+}
+
+// columnFromRequest maps a column of a request to its native definition. The
+// returned warnings describe where the engine cannot represent the logical
+// type exactly.
+func columnFromRequest(gen connectors.SchemaChangeGenerator, col CreateColumnRequest) (connectors.ColumnStructureResponse, []string, error) {
+	column := connectors.ColumnStructureResponse{
+		ColumnName: col.Name,
+		Nullable:   col.Nullable,
+		Identity:   col.AutoIncrement,
+	}
+	if err := validateName("column", col.Name); err != nil {
+		return column, nil, err
+	}
+
+	t, err := connectors.ParseLogicalType(col.Type)
+	if err != nil {
+		return column, nil, err
+	}
+	column.DataType = gen.NativeType(t)
+
+	if col.AutoIncrement {
+		if t.Name != connectors.TypeInt32 && t.Name != connectors.TypeInt64 {
+			return column, nil, fmt.Errorf("%w: autoIncrement requires an int32 or int64 column", ErrInvalidOperation)
+		}
+		if col.Default != "" {
+			return column, nil, fmt.Errorf("%w: autoIncrement columns cannot have a default", ErrInvalidOperation)
+		}
+	}
+
+	if col.Default != "" {
+		value, err := gen.DefaultValue(t, col.Default)
+		if err != nil {
+			return column, nil, err
+		}
+		column.DefaultValue = &value
+	}
+
+	var warnings []string
+	if t.Name == connectors.TypeTimestampTZ && gen.Dialect() == connectors.DialectMySQL {
+		warnings = append(warnings, fmt.Sprintf(
+			"MySQL has no time zone aware type, %s is stored as %s and should hold UTC", col.Name, column.DataType))
+	}
+	return column, warnings, nil
+}
+
+// isInputError reports errors caused by the request rather than the server.
+func isInputError(err error) bool {
+	return errors.Is(err, ErrInvalidOperation) ||
+		errors.Is(err, connectors.ErrInvalidType) ||
+		errors.Is(err, connectors.ErrInvalidDefault)
+}
+
+func containsFold(columns []connectors.ColumnStructureResponse, name string) bool {
+	for _, col := range columns {
+		if strings.EqualFold(col.ColumnName, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,205 @@
+package version
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FormatPlain is an export layout of numbered up scripts with their down
+// scripts in a rollback folder, for tools without a naming convention.
+const FormatPlain = "plain"
+
+// Archive types of an export.
+const (
+	ArchiveZip   = "zip"
+	ArchiveTarGz = "tar.gz"
+)
+
+const (
+	manifestFile     = "manifest.json"
+	repeatableFolder = "repeatable"
+)
+
+var (
+	slugPattern         = regexp.MustCompile(`[^a-z0-9]+`)
+	numericLabelPattern = regexp.MustCompile(`^v?\d+(\.\d+)*$`)
+)
+
+// Export packs the versions of a project into a migration directory archive
+// with a manifest.json that lists every file with its checksum. Script files
+// hold the stored script text unchanged, so their SHA-256 matches the version
+// checksums. Repeatable versions are exported with their up script only,
+// Flyway has no undo for them and the other layouts no place.
+func (r *Repository) Export(req ExportRequest) (*ExportResult, error) {
+	versions, err := r.GetVersions(req.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.States) > 0 {
+		filtered := []Version{}
+		for _, v := range versions {
+			if containsName(req.States, v.State) {
+				filtered = append(filtered, v)
+			}
+		}
+		versions = filtered
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%w: no versions to export", ErrInvalidOperation)
+	}
+
+	scheme, err := r.GetVersionScheme(req.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	manifest := ExportManifest{
+		ProjectID:     req.ProjectID,
+		Format:        req.Format,
+		VersionScheme: scheme,
+		ExportedAt:    now,
+		Versions:      []ExportedVersion{},
+	}
+
+	numbers := exportNumbers(req.Format, versions)
+	var files []archiveFile
+	for i, v := range versions {
+		upPath, downPath := exportPaths(req.Format, numbers[i], exportSlug(v))
+		if v.Kind == KindRepeatable {
+			upPath, downPath = repeatablePath(req.Format, v.Version), ""
+		}
+
+		exported := ExportedVersion{
+			Version:     v.Version,
+			Kind:        v.Kind,
+			State:       v.State,
+			CreatedAt:   v.CreatedAt,
+			AppliedAt:   v.AppliedAt,
+			Description: v.Description,
+			Up:          ExportedScript{File: upPath, Checksum: v.Up.Checksum()},
+		}
+		files = append(files, archiveFile{Path: upPath, Content: []byte(v.Up.Script)})
+
+		if downPath != "" && strings.TrimSpace(v.Down.Script) != "" {
+			exported.Down = &ExportedScript{File: downPath, Checksum: v.Down.Checksum()}
+			files = append(files, archiveFile{Path: downPath, Content: []byte(v.Down.Script)})
+		}
+		manifest.Versions = append(manifest.Versions, exported)
+	}
+
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, archiveFile{Path: manifestFile, Content: content})
+
+	result := &ExportResult{FileName: fmt.Sprintf("project-%d-%s.%s", req.ProjectID, req.Format, req.Archive)}
+	if req.Archive == ArchiveTarGz {
+		result.ContentType = "application/gzip"
+		result.Data, err = writeTarGz(files, now)
+	} else {
+		result.ContentType = "application/zip"
+		result.Data, err = writeZip(files, now)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// exportNumbers returns the version number used in file names. Flyway keeps
+// numeric labels, golang-migrate keeps timestamp labels; otherwise versions
+// are numbered in order, which keeps the order but not the label. Repeatable
+// versions are named, not numbered.
+func exportNumbers(format string, versions []Version) []string {
+	numbers := make([]string, len(versions))
+
+	keep := true
+	count := 0
+	for _, v := range versions {
+		if v.Kind == KindRepeatable {
+			continue
+		}
+		count++
+		switch format {
+		case FormatFlyway:
+			keep = keep && numericLabelPattern.MatchString(v.Version)
+		case FormatGolangMigrate:
+			keep = keep && timestampPattern.MatchString(v.Version)
+		default:
+			keep = false
+		}
+	}
+
+	width := max(len(strconv.Itoa(count)), 4)
+	n := 0
+	for i, v := range versions {
+		if v.Kind == KindRepeatable {
+			continue
+		}
+		n++
+		switch {
+		case keep:
+			numbers[i] = strings.TrimPrefix(v.Version, "v")
+		case format == FormatFlyway:
+			numbers[i] = strconv.Itoa(n)
+		default:
+			numbers[i] = fmt.Sprintf("%0*d", width, n)
+		}
+	}
+	return numbers
+}
+
+func exportPaths(format string, number string, slug string) (string, string) {
+	switch format {
+	case FormatFlyway:
+		return fmt.Sprintf("V%s__%s.sql", number, slug), fmt.Sprintf("U%s__%s.sql", number, slug)
+	case FormatGolangMigrate:
+		return fmt.Sprintf("%s_%s.up.sql", number, slug), fmt.Sprintf("%s_%s.down.sql", number, slug)
+	}
+	return fmt.Sprintf("%s_%s.sql", number, slug), fmt.Sprintf("rollback/%s_%s.sql", number, slug)
+}
+
+// repeatablePath is the file of a repeatable version. Outside of Flyway they go
+// to a folder of their own, which migration tools reading the top level skip.
+func repeatablePath(format string, name string) string {
+	if format == FormatFlyway {
+		return fmt.Sprintf("R__%s.sql", name)
+	}
+	return fmt.Sprintf("%s/%s.sql", repeatableFolder, name)
+}
+
+// exportSlug names a script file after the version description, or its label
+// when it has none.
+func exportSlug(v Version) string {
+	text := v.Version
+	if v.Description != nil && *v.Description != "" {
+		text = *v.Description
+		if i := strings.Index(text, " (imported from "); i > 0 {
+			text = text[:i]
+		}
+	}
+
+	slug := strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(text), "_"), "_")
+	if len(slug) > 48 {
+		slug = strings.TrimRight(slug[:48], "_")
+	}
+	if slug == "" {
+		slug = "version"
+	}
+	return slug
+}
+
+func validExportFormat(format string) bool {
+	switch format {
+	case FormatFlyway, FormatGolangMigrate, FormatPlain:
+		return true
+	}
+	return false
+}
@@ -1,30 +1,855 @@
 package version
 
 import (
+	"backend/connectors"
 	"backend/core"
+	"backend/schemaDiff"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
 func HandleCreateTable(ctx *core.WebContext) error {
+	_, err := ctx.GetUserId()
+	if err != nil {
+		return ctx.Unauthorized(err.Error())
+	}
+
 	var ctr CreateTableRequest
+	if err := ctx.Bind(&ctr); err != nil {
+		return ctx.BadRequest("invalid input")
+	}
 
-	err := ctx.Bind(&ctr)
+	if ctr.ProjectID == 0 || ctr.TableName == "" {
+		return ctx.BadRequest("projectId and tableName are required")
+	}
+
+	conn, err := core.NewConnector(ctx.GetDb(), ctr.ProjectID)
 	if err != nil {
 		return ctx.InternalError(err.Error())
 	}
 
-	repo := NewRepository(ctx)
-	err = repo.CreateTable(ctr)
+	response, err := NewRepository(ctx).CreateTable(conn, ctr)
 	if err != nil {
-		return ctx.InternalError(err.Error())
+		return versionError(ctx, err)
 	}
 
-	return ctx.Sucsess()
+	return ctx.Sucsess(response)
 }
 
 func HandleUpdateTable(ctx *core.WebContext) error {
-	return ctx.Sucsess()
+	_, err := ctx.GetUserId()
+	if err != nil {
+		return ctx.Unauthorized(err.Error())
+	}
+
+	var req AlterTableRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.BadRequest("invalid input")
+	}
+
+	if req.ProjectID == 0 || req.TableName == "" {
+		return ctx.BadRequest("projectId and tableName are required")
+	}
+
+	conn, err := core.NewConnector(ctx.GetDb(), req.ProjectID)
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+
+	response, err := NewRepository(ctx).AlterTable(conn, req)
+	if err != nil {
+		return versionError(ctx, err)
+	}
+
+	return ctx.Sucsess(response)
 }
 
 func HandleDropTable(ctx *core.WebContext) error {
+	_, err := ctx.GetUserId()
+	if err != nil {
+		return ctx.Unauthorized(err.Error())
+	}
+
+	var req DropTableRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.BadRequest("invalid input")
+	}
+
+	if req.ProjectID == 0 || req.TableName == "" {
+		return ctx.BadRequest("projectId and tableName are required")
+	}
+
+	conn, err := core.NewConnector(ctx.GetDb(), req.ProjectID)
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+
+	response, err := NewRepository(ctx).DropTable(conn, req)
+	if err != nil {
+		return versionError(ctx, err)
+	}
+
+	return ctx.Sucsess(response)
+}
+
+func HandleCreateFromDiff(ctx *core.WebContext) error {
+	_, err := ctx.GetUserId()
+	if err != nil {
+		return ctx.Unauthorized(err.Error())
+	}
+
+	var req CreateFromDiffRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.BadRequest("invalid input")
+	}
+
+	if req.ProjectID == 0 || req.Desired.ProjectID == 0 {
+		return ctx.BadRequest("projectId and desired.projectId are required")
+	}
+
+	conn, err := core.NewConnector(ctx.GetDb(), req.ProjectID)
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+
+	live, err := conn.IntrospectDatabase(req.ProjectID)
+	if err != nil {
+		return ctx.InternalError("failed to introspect target database: " + err.Error())
+	}
+
+	desired, _, err := schemaDiff.NewRepository(ctx).LoadState(req.Desired)
+	if errors.Is(err, schemaDiff.ErrStateNotFound) {
+		return ctx.NotFound(err.Error())
+	}
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+	desired = schemaDiff.RenameSchemas(desired, req.SchemaMapping)
+
+	response := CreateFromDiffResponse{
+		Up:     schemaDiff.GenerateMigration(conn, live, desired),
+		Down:   schemaDiff.GenerateMigration(conn, desired, live),
+		Report: schemaDiff.Report(schemaDiff.Compare(live, desired)),
+	}
+
+	if len(response.Up.Statements) == 0 {
+		return ctx.BadRequest("target database already matches the desired state")
+	}
+
+	if req.Preview {
+		return ctx.Sucsess(response)
+	}
+
+	if len(response.Up.Destructive) > 0 && !req.ConfirmDestructive {
+		objects := make([]string, 0, len(response.Up.Destructive))
+		for _, change := range response.Up.Destructive {
+			objects = append(objects, change.Kind+" "+change.Object)
+		}
+		return ctx.Conflict("destructive changes require confirmDestructive: " + strings.Join(objects, ", "))
+	}
+
+	repo := NewRepository(ctx)
+	version, err := repo.InsertVersion(NewVersion{
+		ProjectID: req.ProjectID,
+		Version:   req.Version,
+		Bump:      req.Bump,
+		Up:        NewScript(response.Up.Script),
+		Down:      NewScript(response.Down.Script),
+	})
+	if err != nil {
+		return versionError(ctx, err)
+	}
+	response.Version = &version
+
+	return ctx.Sucsess(response)
+}
+
+func HandleCreateRawVersion(ctx *core.WebContext) error {
+	userID, err := ctx.GetUserId()
+	if err != nil {
+		return ctx.Unauthorized(err.Error())
+	}
+
+	var req RawVersionRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.BadRequest("invalid input")
+	}
+
+	if req.ProjectID == 0 || strings.TrimSpace(req.Up) == "" {
+		return ctx.BadRequest("projectId and up are required")
+	}
+
+	if !validValidateMode(req.Validate) {
+		return ctx.BadRequest("validate must be none, parse or dry_run")
+	}
+
+	if !validKind(req.Kind) {
+		return ctx.BadRequest("kind must be versioned or repeatable")
+	}
+	if req.Kind == KindRepeatable && req.Version == "" {
+		return ctx.BadRequest("version is required as the name of a repeatable version")
+	}
+
+	conn, err := core.NewConnector(ctx.GetDb(), req.ProjectID)
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+
+	migrator := NewMigrator(ctx.GetDb(), conn, req.ProjectID, userID)
+	response, err := NewRepository(ctx).CreateRawVersion(migrator, req)
+	if err != nil {
+		return versionError(ctx, err)
+	}
+
+	return ctx.Sucsess(response)
+}
+
+func HandleApplyVersions(ctx *core.WebContext) error {
+	userID, err := ctx.GetUserId()
+	if err != nil {
+		return ctx.Unauthorized(err.Error())
+	}
+
+	var req ApplyRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.BadRequest("invalid input")
+	}
+
+	if req.ProjectID == 0 {
+		return ctx.BadRequest("projectId is required")
+	}
+
+	if !validTransactionMode(req.TransactionMode) {
+		return ctx.BadRequest("transactionMode must be version, batch or none")
+	}
+
+	conn, err := core.NewConnector(ctx.GetDb(), req.ProjectID)
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+
+	migrator := NewMigrator(ctx.GetDb(), conn, req.ProjectID, userID)
+	if req.LockTimeoutSeconds > 0 {
+		migrator.LockTimeout = time.Duration(req.LockTimeoutSeconds) * time.Second
+	}
+	if req.TransactionMode != "" {
+		migrator.TransactionMode = req.TransactionMode
+	}
+
+	response, err := migrator.Apply()
+	if errors.Is(err, ErrValidationFailed) || errors.Is(err, ErrLocked) || errors.Is(err, ErrLintFailed) {
+		return ctx.Conflict(err.Error())
+	}
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+
+	return ctx.Sucsess(response)
+}
+
+func HandleRollbackVersions(ctx *core.WebContext) error {
+	userID, err := ctx.GetUserId()
+	if err != nil {
+		return ctx.Unauthorized(err.Error())
+	}
+
+	var req RollbackRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.BadRequest("invalid input")
+	}
+
+	if req.ProjectID == 0 {
+		return ctx.BadRequest("projectId is required")
+	}
+
+	if !validTransactionMode(req.TransactionMode) {
+		return ctx.BadRequest("transactionMode must be version, batch or none")
+	}
+
+	conn, err := core.NewConnector(ctx.GetDb(), req.ProjectID)
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+
+	migrator := NewMigrator(ctx.GetDb(), conn, req.ProjectID, userID)
+	if req.LockTimeoutSeconds > 0 {
+		migrator.LockTimeout = time.Duration(req.LockTimeoutSeconds) * time.Second
+	}
+	if req.TransactionMode != "" {
+		migrator.TransactionMode = req.TransactionMode
+	}
+
+	response, err := migrator.Rollback(req.TargetVersionID, req.Force)
+	switch {
+	case errors.Is(err, ErrVersionNotFound):
+		return ctx.NotFound(err.Error())
+	case errors.Is(err, ErrInvalidTarget):
+		return ctx.BadRequest(err.Error())
+	case errors.Is(err, ErrMissingDownScript), errors.Is(err, ErrLocked):
+		return ctx.Conflict(err.Error())
+	case err != nil:
+		return ctx.InternalError(err.Error())
+	}
+
+	return ctx.Sucsess(response)
+}
+
+func HandleValidateVersions(ctx *core.WebContext) error {
+	_, err := ctx.GetUserId()
+	if err != nil {
+		return ctx.Unauthorized(err.Error())
+	}
+
+	projectID, err := strconv.Atoi(ctx.QueryParam("projectId"))
+	if err != nil || projectID == 0 {
+		return ctx.BadRequest("projectId is required")
+	}
+
+	response, err := NewRepository(ctx).Validate(projectID)
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+
+	return ctx.Sucsess(response)
+}
+
+func HandleGetMigrationLock(ctx *core.WebContext) error {
+	_, err := ctx.GetUserId()
+	if err != nil {
+		return ctx.Unauthorized(err.Error())
+	}
+
+	projectID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return ctx.BadRequest("invalid project id")
+	}
+
+	lock, err := NewRepository(ctx).GetMigrationLock(projectID)
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+	if lock == nil {
+		return ctx.NotFound("no migration lock held")
+	}
+
+	return ctx.Sucsess(lock)
+}
+
+// HandleForceUnlock releases a stuck migration lock. Only the project owner
+// may do this, because it aborts a migration that might still be running.
+func HandleForceUnlock(ctx *core.WebContext) error {
+	userID, err := ctx.GetUserId()
+	if err != nil {
+		return ctx.Unauthorized(err.Error())
+	}
+
+	projectID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return ctx.BadRequest("invalid project id")
+	}
+
+	repo := NewRepository(ctx)
+	ownerID, err := repo.GetProjectOwner(projectID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ctx.NotFound("project not found")
+	}
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+	if ownerID != userID {
+		return ctx.Forbidden("only the project owner can force unlock")
+	}
+
+	lock, err := repo.ForceUnlock(projectID)
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+	if lock == nil {
+		return ctx.NotFound("no migration lock held")
+	}
+
+	return ctx.Sucsess(lock)
+}
+
+func validKind(kind string) bool {
+	switch kind {
+	case "", KindVersioned, KindRepeatable:
+		return true
+	}
+	return false
+}
+
+func validTransactionMode(mode string) bool {
+	switch mode {
+	case "", TransactionPerVersion, TransactionBatch, TransactionNone:
+		return true
+	}
+	return false
+}
+
+// HandlePlanVersions shows what an apply with the same options would run.
+func HandlePlanVersions(ctx *core.WebContext) error {
+	userID, err := ctx.GetUserId()
+	if err != nil {
+		return ctx.Unauthorized(err.Error())
+	}
+
+	var req ApplyRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.BadRequest("invalid input")
+	}
+
+	if req.ProjectID == 0 {
+		return ctx.BadRequest("projectId is required")
+	}
+
+	if !validTransactionMode(req.TransactionMode) {
+		return ctx.BadRequest("transactionMode must be version, batch or none")
+	}
+
+	conn, err := core.NewConnector(ctx.GetDb(), req.ProjectID)
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+
+	migrator := NewMigrator(ctx.GetDb(), conn, req.ProjectID, userID)
+	if req.TransactionMode != "" {
+		migrator.TransactionMode = req.TransactionMode
+	}
+
+	response, err := migrator.Plan()
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+
+	return ctx.Sucsess(response)
+}
+
+// versionError maps errors of creating a version to a response.
+func versionError(ctx *core.WebContext, err error) error {
+	switch {
+	case errors.Is(err, ErrVersionExists), errors.Is(err, ErrDestructiveChange), errors.Is(err, ErrVersionNotPending),
+		errors.Is(err, ErrLocked), errors.Is(err, ErrAlreadyApplied), errors.Is(err, ErrLintFailed),
+		errors.Is(err, ErrSnapshotMissing), errors.Is(err, ErrNotSquashable):
+		return ctx.Conflict(err.Error())
+	case errors.Is(err, ErrInvalidVersion), errors.Is(err, ErrDryRunFailed), errors.Is(err, ErrInvalidArchive), errors.Is(err, ErrInvalidRange),
+		isInputError(err):
+		return ctx.BadRequest(err.Error())
+	case errors.Is(err, connectors.ErrTableNotFound), errors.Is(err, ErrVersionNotFound):
+		return ctx.NotFound(err.Error())
+	}
+	return ctx.InternalError(err.Error())
+}
+
+func HandleChangeIndex(ctx *core.WebContext) error {
+	_, err := ctx.GetUserId()
+	if err != nil {
+		return ctx.Unauthorized(err.Error())
+	}
+
+	var req IndexRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.BadRequest("invalid input")
+	}
+
+	if req.ProjectID == 0 || req.TableName == "" {
+		return ctx.BadRequest("projectId and tableName are required")
+	}
+
+	conn, err := core.NewConnector(ctx.GetDb(), req.ProjectID)
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+
+	response, err := NewRepository(ctx).ChangeIndex(conn, req)
+	if err != nil {
+		return versionError(ctx, err)
+	}
+
+	return ctx.Sucsess(response)
+}
+
+func HandleChangeConstraint(ctx *core.WebContext) error {
+	_, err := ctx.GetUserId()
+	if err != nil {
+		return ctx.Unauthorized(err.Error())
+	}
+
+	var req ConstraintRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.BadRequest("invalid input")
+	}
+
+	if req.ProjectID == 0 || req.TableName == "" {
+		return ctx.BadRequest("projectId and tableName are required")
+	}
+
+	conn, err := core.NewConnector(ctx.GetDb(), req.ProjectID)
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+
+	response, err := NewRepository(ctx).ChangeConstraint(conn, req)
+	if err != nil {
+		return versionError(ctx, err)
+	}
+
+	return ctx.Sucsess(response)
+}
+
+var versionStates = map[string]bool{
+	StatePending:    true,
+	StateCompleted:  true,
+	StateFailed:     true,
+	StateRolledBack: true,
+	StateSquashed:   true,
+}
+
+func HandleGetVersions(ctx *core.WebContext) error {
+	_, err := ctx.GetUserId()
+	if err != nil {
+		return ctx.Unauthorized(err.Error())
+	}
+
+	projectID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return ctx.BadRequest("invalid project id")
+	}
+
+	filter := VersionFilter{ProjectID: projectID, States: []string{}, Limit: 50}
+	if value := ctx.QueryParam("state"); value != "" {
+		for _, state := range strings.Split(value, ",") {
+			state = strings.TrimSpace(state)
+			if !versionStates[state] {
+				return ctx.BadRequest("invalid state " + state)
+			}
+			filter.States = append(filter.States, state)
+		}
+	}
+
+	filter.Kind = ctx.QueryParam("kind")
+	if filter.Kind != "" && !validKind(filter.Kind) {
+		return ctx.BadRequest("kind must be versioned or repeatable")
+	}
+
+	if value := ctx.QueryParam("limit"); value != "" {
+		filter.Limit, err = strconv.Atoi(value)
+		if err != nil || filter.Limit < 1 {
+			return ctx.BadRequest("invalid limit")
+		}
+		if filter.Limit > 500 {
+			filter.Limit = 500
+		}
+	}
+
+	if value := ctx.QueryParam("offset"); value != "" {
+		filter.Offset, err = strconv.Atoi(value)
+		if err != nil || filter.Offset < 0 {
+			return ctx.BadRequest("invalid offset")
+		}
+	}
+
+	response, err := NewRepository(ctx).ListVersions(filter)
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+
+	return ctx.Sucsess(response)
+}
+
+func HandleGetVersion(ctx *core.WebContext) error {
+	_, err := ctx.GetUserId()
+	if err != nil {
+		return ctx.Unauthorized(err.Error())
+	}
+
+	projectID, versionID, err := versionParams(ctx)
+	if err != nil {
+		return ctx.BadRequest(err.Error())
+	}
+
+	detail, err := NewRepository(ctx).GetVersionDetail(projectID, versionID)
+	if err != nil {
+		return versionError(ctx, err)
+	}
+
+	return ctx.Sucsess(detail)
+}
+
+func HandleUpdateVersion(ctx *core.WebContext) error {
+	userID, err := ctx.GetUserId()
+	if err != nil {
+		return ctx.Unauthorized(err.Error())
+	}
+
+	projectID, versionID, err := versionParams(ctx)
+	if err != nil {
+		return ctx.BadRequest(err.Error())
+	}
+
+	var req UpdateVersionRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.BadRequest("invalid input")
+	}
+
+	version, err := NewRepository(ctx).UpdateVersion(projectID, versionID, userID, req)
+	if err != nil {
+		return versionError(ctx, err)
+	}
+
+	return ctx.Sucsess(version)
+}
+
+func HandleDeleteVersion(ctx *core.WebContext) error {
+	_, err := ctx.GetUserId()
+	if err != nil {
+		return ctx.Unauthorized(err.Error())
+	}
+
+	projectID, versionID, err := versionParams(ctx)
+	if err != nil {
+		return ctx.BadRequest(err.Error())
+	}
+
+	if err := NewRepository(ctx).DeleteVersion(projectID, versionID); err != nil {
+		return versionError(ctx, err)
+	}
+
 	return ctx.Sucsess()
 }
+
+func versionParams(ctx *core.WebContext) (int, int, error) {
+	projectID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return 0, 0, errors.New("invalid project id")
+	}
+
+	versionID, err := strconv.Atoi(ctx.Param("versionId"))
+	if err != nil {
+		return 0, 0, errors.New("invalid version id")
+	}
+
+	return projectID, versionID, nil
+}
+
+// HandleImportVersions creates versions from an uploaded Flyway, golang-migrate
+// or Liquibase migration directory. The archive is sent as the multipart field
+// "file", with optional "format" and "baseline" fields.
+func HandleImportVersions(ctx *core.WebContext) error {
+	userID, err := ctx.GetUserId()
+	if err != nil {
+		return ctx.Unauthorized(err.Error())
+	}
+
+	projectID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return ctx.BadRequest("invalid project id")
+	}
+
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		return ctx.BadRequest("file is required")
+	}
+	if header.Size > MaxArchiveSize {
+		return ctx.BadRequest("archive is too large")
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+
+	req := ImportRequest{
+		ProjectID: projectID,
+		Format:    ctx.FormValue("format"),
+		Baseline:  ctx.FormValue("baseline") == "true",
+	}
+	if !validImportFormat(req.Format) {
+		return ctx.BadRequest("format must be flyway, golang-migrate or liquibase")
+	}
+
+	conn, err := core.NewConnector(ctx.GetDb(), projectID)
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+
+	response, err := NewMigrator(ctx.GetDb(), conn, projectID, userID).Import(req, data)
+	if err != nil {
+		return versionError(ctx, err)
+	}
+
+	return ctx.Sucsess(response)
+}
+
+// HandleExportVersions downloads the versions of a project as a migration
+// directory archive. Query parameters: format (golang-migrate, flyway or
+// plain), archive (zip or tar.gz) and an optional comma separated state list.
+func HandleExportVersions(ctx *core.WebContext) error {
+	_, err := ctx.GetUserId()
+	if err != nil {
+		return ctx.Unauthorized(err.Error())
+	}
+
+	projectID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return ctx.BadRequest("invalid project id")
+	}
+
+	req := ExportRequest{
+		ProjectID: projectID,
+		Format:    ctx.QueryParam("format"),
+		Archive:   ctx.QueryParam("archive"),
+	}
+	if req.Format == "" {
+		req.Format = FormatGolangMigrate
+	}
+	if !validExportFormat(req.Format) {
+		return ctx.BadRequest("format must be golang-migrate, flyway or plain")
+	}
+	if req.Archive == "" {
+		req.Archive = ArchiveZip
+	}
+	if req.Archive != ArchiveZip && req.Archive != ArchiveTarGz {
+		return ctx.BadRequest("archive must be zip or tar.gz")
+	}
+	if value := ctx.QueryParam("state"); value != "" {
+		for _, state := range strings.Split(value, ",") {
+			state = strings.TrimSpace(state)
+			if !versionStates[state] {
+				return ctx.BadRequest("invalid state " + state)
+			}
+			req.States = append(req.States, state)
+		}
+	}
+
+	result, err := NewRepository(ctx).Export(req)
+	if err != nil {
+		return versionError(ctx, err)
+	}
+
+	ctx.Response().Header().Set("Content-Disposition", `attachment; filename="`+result.FileName+`"`)
+	return ctx.Blob(http.StatusOK, result.ContentType, result.Data)
+}
+
+func HandleBaseline(ctx *core.WebContext) error {
+	userID, err := ctx.GetUserId()
+	if err != nil {
+		return ctx.Unauthorized(err.Error())
+	}
+
+	var req BaselineRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.BadRequest("invalid input")
+	}
+
+	if req.ProjectID == 0 {
+		return ctx.BadRequest("projectId is required")
+	}
+
+	conn, err := core.NewConnector(ctx.GetDb(), req.ProjectID)
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+
+	migrator := NewMigrator(ctx.GetDb(), conn, req.ProjectID, userID)
+	if req.LockTimeoutSeconds > 0 {
+		migrator.LockTimeout = time.Duration(req.LockTimeoutSeconds) * time.Second
+	}
+
+	response, err := migrator.Baseline(req)
+	if err != nil {
+		return versionError(ctx, err)
+	}
+
+	return ctx.Sucsess(response)
+}
+
+func HandleSquashVersions(ctx *core.WebContext) error {
+	userID, err := ctx.GetUserId()
+	if err != nil {
+		return ctx.Unauthorized(err.Error())
+	}
+
+	var req SquashRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.BadRequest("invalid input")
+	}
+
+	if req.ProjectID == 0 || req.FromVersionID == 0 || req.ToVersionID == 0 {
+		return ctx.BadRequest("projectId, fromVersionId and toVersionId are required")
+	}
+
+	conn, err := core.NewConnector(ctx.GetDb(), req.ProjectID)
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+
+	migrator := NewMigrator(ctx.GetDb(), conn, req.ProjectID, userID)
+	if req.LockTimeoutSeconds > 0 {
+		migrator.LockTimeout = time.Duration(req.LockTimeoutSeconds) * time.Second
+	}
+
+	response, err := migrator.Squash(req)
+	if err != nil {
+		return versionError(ctx, err)
+	}
+
+	return ctx.Sucsess(response)
+}
+
+func HandleGetLintRules(ctx *core.WebContext) error {
+	_, err := ctx.GetUserId()
+	if err != nil {
+		return ctx.Unauthorized(err.Error())
+	}
+
+	projectID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return ctx.BadRequest("invalid project id")
+	}
+
+	rules, err := NewRepository(ctx).GetLintRules(projectID)
+	if err != nil {
+		return ctx.InternalError(err.Error())
+	}
+
+	return ctx.Sucsess(rules)
+}
+
+func HandleUpdateLintRules(ctx *core.WebContext) error {
+	_, err := ctx.GetUserId()
+	if err != nil {
+		return ctx.Unauthorized(err.Error())
+	}
+
+	projectID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return ctx.BadRequest("invalid project id")
+	}
+
+	var req UpdateLintRulesRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.BadRequest("invalid input")
+	}
+	if len(req.Rules) == 0 {
+		return ctx.BadRequest("rules are required")
+	}
+
+	rules, err := NewRepository(ctx).UpdateLintRules(projectID, req.Rules)
+	if err != nil {
+		return versionError(ctx, err)
+	}
+
+	return ctx.Sucsess(rules)
+}
@@ -0,0 +1,685 @@
+package version
+
+import (
+	"backend/connectors"
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Migration directory layouts that can be imported.
+const (
+	FormatFlyway        = "flyway"
+	FormatGolangMigrate = "golang-migrate"
+	FormatLiquibase     = "liquibase"
+)
+
+const operationBaseline = "baseline"
+
+var (
+	flywayPattern           = regexp.MustCompile(`^([VU])(\d+(?:[._]\d+)*)__(.+)\.sql$`)
+	flywayRepeatablePattern = regexp.MustCompile(`^R__(.+)\.sql$`)
+	migratePattern          = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+	repeatableNamePattern   = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+	liquibaseHeader         = regexp.MustCompile(`(?i)^--\s*liquibase\s+formatted\s+sql`)
+	liquibaseChangeset      = regexp.MustCompile(`(?i)^--\s*changeset\s+([^:\s]+):(\S+)`)
+	liquibaseRollback       = regexp.MustCompile(`(?i)^--\s*rollback\b ?(.*)$`)
+	liquibaseComment        = regexp.MustCompile(`(?i)^--\s*comment:?\s*(.*)$`)
+	liquibaseDirective      = regexp.MustCompile(`(?i)^--\s*(precondition|validCheckSum|ignoreLines|property|include)`)
+)
+
+// importedMigration is one migration read from an archive.
+type importedMigration struct {
+	// key identifies the migration in the history table of its tool.
+	key         string
+	order       []uint64
+	source      string
+	description string
+	up          string
+	down        string
+	// repeatable migrations are keyed by their name.
+	repeatable bool
+}
+
+// Import creates pending versions from a migration directory archive. With
+// req.Baseline the history table of the original tool is read from the target
+// and the migrations it lists are marked completed without running them.
+// Repeatable migrations are never baselined, apply runs them once and they
+// are written to be run again.
+func (m *Migrator) Import(req ImportRequest, data []byte) (*ImportResponse, error) {
+	files, err := readArchive(data)
+	if err != nil {
+		return nil, err
+	}
+
+	format := req.Format
+	if format == "" {
+		if format, err = detectFormat(files); err != nil {
+			return nil, err
+		}
+	}
+
+	migrations, warnings, err := parseMigrations(format, files)
+	if err != nil {
+		return nil, err
+	}
+	if len(migrations) == 0 {
+		return nil, fmt.Errorf("%w: no %s migrations found in the archive", ErrInvalidOperation, format)
+	}
+
+	scheme, err := m.repo.GetVersionScheme(m.projectID)
+	if err != nil {
+		return nil, err
+	}
+	_, latestKey, err := m.repo.GetLatestVersion(m.projectID, false)
+	if err != nil {
+		return nil, err
+	}
+	labels := importLabels(scheme, format, migrations, latestKey)
+	if labels == nil {
+		warnings = append(warnings, "the original version numbers do not fit the project's "+scheme+" scheme or existing versions, new labels were generated")
+	}
+
+	response := &ImportResponse{
+		Format:   format,
+		Versions: []ImportedVersion{},
+		Warnings: warnings,
+	}
+
+	ctx := context.Background()
+	var target *sql.Conn
+	applied := func(importedMigration) bool { return false }
+	if req.Baseline {
+		db, conn, err := m.openTarget(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer db.Close()
+		defer conn.Close()
+		target = conn
+
+		unlock, err := m.lock(ctx, target, operationBaseline)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+
+		if _, err := m.reconcile(ctx, target); err != nil {
+			return nil, fmt.Errorf("failed to reconcile with %s: %w", connectors.HistoryTableName, err)
+		}
+
+		applied, err = m.foreignHistory(ctx, target, format, response)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Versions are inserted first and removed again when one fails, so an
+	// import creates all of its versions or none.
+	versions := make([]Version, 0, len(migrations))
+	for i, migration := range migrations {
+		description := "imported from " + migration.source
+		if migration.description != "" {
+			description = migration.description + " (" + description + ")"
+		}
+
+		nv := NewVersion{
+			ProjectID:        m.projectID,
+			Up:               NewScript(migration.up),
+			Down:             NewScript(migration.down),
+			AuthorID:         m.userID,
+			Description:      description,
+			AcceptLintErrors: true,
+		}
+		if labels != nil {
+			nv.Version = labels[i]
+		}
+		if migration.repeatable {
+			nv.Kind, nv.Version = KindRepeatable, migration.key
+		}
+
+		version, err := m.repo.InsertVersion(nv)
+		if err != nil {
+			if removeErr := m.repo.removeVersions(m.projectID, versions); removeErr != nil {
+				return nil, fmt.Errorf("failed to import %s: %w, removing the %d versions imported before it failed: %v", migration.source, err, len(versions), removeErr)
+			}
+			return nil, fmt.Errorf("failed to import %s, no versions were imported: %w", migration.source, err)
+		}
+		versions = append(versions, version)
+	}
+	response.Imported = len(versions)
+
+	for i, migration := range migrations {
+		version := versions[i]
+		imported := ImportedVersion{
+			VersionRef:    VersionRef{VersionID: version.Id, Version: version.Version},
+			Source:        migration.source,
+			SourceVersion: migration.key,
+		}
+		if target != nil && !migration.repeatable && applied(migration) {
+			if err := m.markApplied(ctx, target, version, "baseline from "+format+" history"); err != nil {
+				return nil, fmt.Errorf("failed to baseline %s after %d of %d versions were imported and %d baselined, the rest stay pending: %w",
+					migration.source, response.Imported, len(migrations), response.Baselined, err)
+			}
+			imported.Baselined = true
+			response.Baselined++
+		} else if err := lintError(version.Version, version.Lint); err != nil {
+			response.Warnings = append(response.Warnings, fmt.Sprintf(
+				"%s: %v, apply refuses to run it until the script or the rules change", migration.source, err))
+		}
+		response.Versions = append(response.Versions, imported)
+	}
+
+	if req.Baseline {
+		for i := 1; i < len(response.Versions); i++ {
+			if response.Versions[i].Baselined && !response.Versions[i-1].Baselined {
+				response.Warnings = append(response.Warnings, fmt.Sprintf(
+					"%s is applied on the target but %s before it is not, apply runs it out of order",
+					response.Versions[i].Source, response.Versions[i-1].Source))
+				break
+			}
+		}
+	}
+
+	return response, nil
+}
+
+// markApplied records a version as applied on the target without running it.
+func (m *Migrator) markApplied(ctx context.Context, target connectors.Session, v Version, note string) error {
+	err := m.conn.RecordHistory(ctx, target, connectors.HistoryEntry{
+		VersionID: v.Id,
+		Version:   v.Version,
+		Operation: connectors.HistoryOperationApply,
+		Checksum:  v.Up.Checksum(),
+		AppliedBy: m.userID,
+		Success:   true,
+	})
+	if err != nil {
+		return err
+	}
+	if err := m.repo.SetVersionState(v.Id, StateCompleted); err != nil {
+		return err
+	}
+	if err := m.repo.SetAppliedChecksum(v.Id, v.Up.Checksum()); err != nil {
+		return err
+	}
+	return m.repo.CreateAudit(v.Id, m.userID, note)
+}
+
+func validImportFormat(format string) bool {
+	switch format {
+	case "", FormatFlyway, FormatGolangMigrate, FormatLiquibase:
+		return true
+	}
+	return false
+}
+
+func detectFormat(files []archiveFile) (string, error) {
+	counts := map[string]int{}
+	for _, f := range files {
+		name := path.Base(f.Path)
+		if !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+		switch {
+		case liquibaseHeader.MatchString(firstLine(f.Content)):
+			counts[FormatLiquibase]++
+		case migratePattern.MatchString(name):
+			counts[FormatGolangMigrate]++
+		case flywayPattern.MatchString(name), flywayRepeatablePattern.MatchString(name):
+			counts[FormatFlyway]++
+		}
+	}
+
+	switch len(counts) {
+	case 0:
+		return "", fmt.Errorf("%w: no Flyway, golang-migrate or Liquibase migrations found", ErrInvalidOperation)
+	case 1:
+		for format := range counts {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("%w: the archive mixes migration formats, pass the format explicitly", ErrInvalidOperation)
+}
+
+func parseMigrations(format string, files []archiveFile) ([]importedMigration, []string, error) {
+	switch format {
+	case FormatFlyway:
+		return parseFlyway(files)
+	case FormatGolangMigrate:
+		return parseGolangMigrate(files)
+	case FormatLiquibase:
+		return parseLiquibase(files)
+	}
+	return nil, nil, fmt.Errorf("%w: unknown format %q, use flyway, golang-migrate or liquibase", ErrInvalidOperation, format)
+}
+
+// parseFlyway reads V (versioned), U (undo) and R (repeatable) migrations.
+// Versions compare numerically part by part, like Flyway does, so 1.10
+// follows 1.9.
+func parseFlyway(files []archiveFile) ([]importedMigration, []string, error) {
+	byKey := map[string]*importedMigration{}
+	undo := map[string]string{}
+	repeatables := map[string]*importedMigration{}
+	var warnings []string
+
+	for _, f := range files {
+		name := path.Base(f.Path)
+		if !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+		if match := flywayRepeatablePattern.FindStringSubmatch(name); match != nil {
+			if err := addRepeatable(repeatables, f, match[1]); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		match := flywayPattern.FindStringSubmatch(name)
+		if match == nil {
+			warnings = append(warnings, "skipped "+f.Path+", not a Flyway migration")
+			continue
+		}
+
+		order, err := parseVersionParts(strings.ReplaceAll(match[2], "_", "."))
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %s: %v", ErrInvalidOperation, f.Path, err)
+		}
+		key := flywayKey(order)
+
+		if match[1] == "U" {
+			if _, ok := undo[key]; ok {
+				return nil, nil, fmt.Errorf("%w: two undo migrations for version %s", ErrInvalidOperation, key)
+			}
+			undo[key] = string(f.Content)
+			continue
+		}
+		if existing, ok := byKey[key]; ok {
+			return nil, nil, fmt.Errorf("%w: %s and %s have the same version %s", ErrInvalidOperation, existing.source, f.Path, key)
+		}
+		byKey[key] = &importedMigration{
+			key:         key,
+			order:       order,
+			source:      f.Path,
+			description: strings.ReplaceAll(match[3], "_", " "),
+			up:          string(f.Content),
+		}
+	}
+
+	for key, down := range undo {
+		migration, ok := byKey[key]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: undo migration for version %s has no versioned migration", ErrInvalidOperation, key)
+		}
+		migration.down = down
+	}
+
+	return append(sortedMigrations(byKey), sortedRepeatables(repeatables)...), warnings, nil
+}
+
+// parseGolangMigrate reads up and down migrations, and repeatable ones from a
+// repeatable folder as written by Export.
+func parseGolangMigrate(files []archiveFile) ([]importedMigration, []string, error) {
+	byKey := map[string]*importedMigration{}
+	repeatables := map[string]*importedMigration{}
+	var warnings []string
+
+	for _, f := range files {
+		name := path.Base(f.Path)
+		if !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+		if path.Base(path.Dir(f.Path)) == repeatableFolder {
+			if err := addRepeatable(repeatables, f, strings.TrimSuffix(name, ".sql")); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		match := migratePattern.FindStringSubmatch(name)
+		if match == nil {
+			warnings = append(warnings, "skipped "+f.Path+", not a golang-migrate migration")
+			continue
+		}
+
+		number, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %s: version out of range", ErrInvalidOperation, f.Path)
+		}
+		key := strconv.FormatUint(number, 10)
+
+		migration, ok := byKey[key]
+		if !ok {
+			migration = &importedMigration{
+				key:         key,
+				order:       []uint64{number},
+				description: strings.ReplaceAll(match[2], "_", " "),
+			}
+			byKey[key] = migration
+		}
+
+		script := &migration.up
+		if match[3] == "down" {
+			script = &migration.down
+		}
+		if *script != "" {
+			return nil, nil, fmt.Errorf("%w: two %s migrations for version %s", ErrInvalidOperation, match[3], key)
+		}
+		*script = string(f.Content)
+		if match[3] == "up" {
+			migration.source = f.Path
+		}
+	}
+
+	for key, migration := range byKey {
+		if migration.source == "" {
+			return nil, nil, fmt.Errorf("%w: version %s has a down but no up migration", ErrInvalidOperation, key)
+		}
+	}
+
+	return append(sortedMigrations(byKey), sortedRepeatables(repeatables)...), warnings, nil
+}
+
+// addRepeatable adds a repeatable migration named after its file. Characters
+// a version name cannot hold are replaced.
+func addRepeatable(repeatables map[string]*importedMigration, f archiveFile, name string) error {
+	key := strings.Trim(repeatableNamePattern.ReplaceAllString(name, "_"), "_")
+	if key == "" || !unicode.IsLetter(rune(key[0])) {
+		key = "r_" + key
+	}
+	if len(key) > 50 {
+		key = key[:50]
+	}
+
+	if existing, ok := repeatables[key]; ok {
+		return fmt.Errorf("%w: %s and %s are both named %s", ErrInvalidOperation, existing.source, f.Path, key)
+	}
+	repeatables[key] = &importedMigration{
+		key:         key,
+		source:      f.Path,
+		description: strings.ReplaceAll(name, "_", " "),
+		up:          string(f.Content),
+		repeatable:  true,
+	}
+	return nil
+}
+
+// parseLiquibase reads Liquibase formatted SQL changelogs. Every changeset
+// becomes a version, in file name order and then in the order of the file.
+// Rollback comments make up the down script.
+func parseLiquibase(files []archiveFile) ([]importedMigration, []string, error) {
+	var migrations []importedMigration
+	var warnings []string
+	seen := map[string]bool{}
+
+	for _, f := range files {
+		if !strings.HasSuffix(f.Path, ".sql") {
+			continue
+		}
+		if !liquibaseHeader.MatchString(firstLine(f.Content)) {
+			warnings = append(warnings, "skipped "+f.Path+", not a Liquibase formatted SQL changelog")
+			continue
+		}
+
+		var current *importedMigration
+		var up, down []string
+		flush := func() {
+			if current != nil {
+				current.up = strings.TrimSpace(strings.Join(up, "\n")) + "\n"
+				current.down = strings.TrimSpace(strings.Join(down, "\n"))
+				if current.down != "" {
+					current.down += "\n"
+				}
+				migrations = append(migrations, *current)
+			}
+			up, down = nil, nil
+		}
+
+		for _, line := range strings.Split(string(f.Content), "\n") {
+			trimmed := strings.TrimSpace(line)
+			if match := liquibaseChangeset.FindStringSubmatch(trimmed); match != nil {
+				flush()
+				key := match[1] + ":" + match[2]
+				if seen[key] {
+					return nil, nil, fmt.Errorf("%w: changeset %s is defined twice", ErrInvalidOperation, key)
+				}
+				seen[key] = true
+				current = &importedMigration{key: key, source: f.Path + " " + key}
+				continue
+			}
+			if current == nil {
+				continue
+			}
+
+			switch match := liquibaseRollback.FindStringSubmatch(trimmed); {
+			case match != nil:
+				if rollback := strings.TrimSpace(match[1]); !strings.EqualFold(rollback, "empty") && !strings.EqualFold(rollback, "not required") {
+					down = append(down, match[1])
+				}
+			case liquibaseComment.MatchString(trimmed):
+				current.description = liquibaseComment.FindStringSubmatch(trimmed)[1]
+			case liquibaseDirective.MatchString(trimmed):
+			default:
+				up = append(up, strings.TrimRight(line, "\r"))
+			}
+		}
+		flush()
+	}
+
+	return migrations, warnings, nil
+}
+
+func parseVersionParts(version string) ([]uint64, error) {
+	var parts []uint64
+	for _, part := range strings.Split(version, ".") {
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q", version)
+		}
+		parts = append(parts, n)
+	}
+	// Trailing zero parts do not change a version, 1.0 is the same as 1.
+	for len(parts) > 1 && parts[len(parts)-1] == 0 {
+		parts = parts[:len(parts)-1]
+	}
+	return parts, nil
+}
+
+func flywayKey(parts []uint64) string {
+	text := make([]string, len(parts))
+	for i, part := range parts {
+		text[i] = strconv.FormatUint(part, 10)
+	}
+	return strings.Join(text, ".")
+}
+
+func compareParts(a []uint64, b []uint64) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y uint64
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func sortedMigrations(byKey map[string]*importedMigration) []importedMigration {
+	migrations := make([]importedMigration, 0, len(byKey))
+	for _, migration := range byKey {
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return compareParts(migrations[i].order, migrations[j].order) < 0
+	})
+	return migrations
+}
+
+func sortedRepeatables(byKey map[string]*importedMigration) []importedMigration {
+	migrations := make([]importedMigration, 0, len(byKey))
+	for _, migration := range byKey {
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].key < migrations[j].key
+	})
+	return migrations
+}
+
+// importLabels keeps the original version numbers as labels where they fit
+// the project's scheme: Flyway versions with up to three parts for semver and
+// 14 digit numbers for timestamps. It returns nil when any label does not fit
+// or would not sort after the project's latest version. Repeatable migrations
+// keep their name and are skipped.
+func importLabels(scheme string, format string, migrations []importedMigration, latestKey string) []string {
+	if format == FormatLiquibase {
+		return nil
+	}
+
+	labels := make([]string, len(migrations))
+	previous := latestKey
+	for i, migration := range migrations {
+		if migration.repeatable {
+			continue
+		}
+		switch {
+		case scheme == SchemeSemver && format == FormatFlyway && len(migration.order) <= 3:
+			parts := append(append([]uint64{}, migration.order...), 0, 0)
+			labels[i] = fmt.Sprintf("v%d.%d.%d", parts[0], parts[1], parts[2])
+		case scheme == SchemeTimestamp && len(migration.order) == 1:
+			labels[i] = migration.key
+		default:
+			return nil
+		}
+
+		sortKey, err := SortKey(scheme, labels[i])
+		if err != nil || sortKey <= previous {
+			return nil
+		}
+		previous = sortKey
+	}
+	return labels
+}
+
+// foreignHistory reads the history table of the original migration tool on
+// the target and returns which imported migrations it lists as applied.
+func (m *Migrator) foreignHistory(ctx context.Context, target *sql.Conn, format string, response *ImportResponse) (func(importedMigration) bool, error) {
+	var table, query string
+	switch format {
+	case FormatFlyway:
+		table = "flyway_schema_history"
+		query = `SELECT version, type, success FROM flyway_schema_history WHERE version IS NOT NULL ORDER BY installed_rank`
+	case FormatGolangMigrate:
+		table = "schema_migrations"
+		query = `SELECT version, dirty FROM schema_migrations`
+	case FormatLiquibase:
+		table = "databasechangelog"
+		query = `SELECT author, id FROM databasechangelog WHERE exectype IN ('EXECUTED', 'RERAN', 'MARK_RAN')`
+	}
+
+	rows, err := target.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot read %s on the target for the baseline: %v", ErrInvalidOperation, table, err)
+	}
+	defer rows.Close()
+
+	applied := map[string]bool{}
+	var baseline []uint64
+	var latest uint64
+	var dirty bool
+
+	for rows.Next() {
+		switch format {
+		case FormatFlyway:
+			var version, kind string
+			var success any
+			if err := rows.Scan(&version, &kind, &success); err != nil {
+				return nil, err
+			}
+			parts, err := parseVersionParts(version)
+			if err != nil || !truthy(success) {
+				continue
+			}
+			switch kind {
+			case "BASELINE":
+				baseline = parts
+			case "UNDO_SQL":
+				applied[flywayKey(parts)] = false
+			default:
+				applied[flywayKey(parts)] = true
+			}
+		case FormatGolangMigrate:
+			var flag any
+			if err := rows.Scan(&latest, &flag); err != nil {
+				return nil, err
+			}
+			dirty = truthy(flag)
+		case FormatLiquibase:
+			var author, id string
+			if err := rows.Scan(&author, &id); err != nil {
+				return nil, err
+			}
+			applied[author+":"+id] = true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if dirty {
+		response.Warnings = append(response.Warnings, fmt.Sprintf(
+			"schema_migrations is dirty at version %d, that version is left pending", latest))
+	}
+
+	return func(migration importedMigration) bool {
+		switch format {
+		case FormatFlyway:
+			if isApplied, ok := applied[migration.key]; ok {
+				return isApplied
+			}
+			return baseline != nil && compareParts(migration.order, baseline) <= 0
+		case FormatGolangMigrate:
+			return migration.order[0] < latest || migration.order[0] == latest && !dirty
+		}
+		return applied[migration.key]
+	}, nil
+}
+
+// truthy reads a boolean column, which engines return as bool, integer or bit
+// bytes.
+func truthy(value any) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case int64:
+		return v != 0
+	case []byte:
+		return len(v) > 0 && (v[0] == 1 || v[0] == '1' || v[0] == 't' || v[0] == 'T')
+	case string:
+		return v == "1" || strings.EqualFold(v, "t") || strings.EqualFold(v, "true")
+	}
+	return false
+}
+
+func firstLine(content []byte) string {
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+	for _, line := range strings.Split(string(content), "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			return trimmed
+		}
+	}
+	return ""
+}
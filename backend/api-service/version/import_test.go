@@ -0,0 +1,177 @@
+package version
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// parsedMigration is the part of an importedMigration the parser tests check.
+type parsedMigration struct {
+	key         string
+	description string
+	up          string
+	down        string
+	repeatable  bool
+}
+
+func parsed(migrations []importedMigration) []parsedMigration {
+	out := make([]parsedMigration, len(migrations))
+	for i, m := range migrations {
+		out[i] = parsedMigration{key: m.key, description: m.description, up: m.up, down: m.down, repeatable: m.repeatable}
+	}
+	return out
+}
+
+func files(pairs ...string) []archiveFile {
+	var out []archiveFile
+	for i := 0; i+1 < len(pairs); i += 2 {
+		out = append(out, archiveFile{Path: pairs[i], Content: []byte(pairs[i+1])})
+	}
+	return out
+}
+
+func TestParseMigrations(t *testing.T) {
+	tests := []struct {
+		name         string
+		format       string
+		files        []archiveFile
+		want         []parsedMigration
+		wantWarnings int
+		wantErr      bool
+	}{
+		{
+			name:   "flyway numeric order and undo",
+			format: FormatFlyway,
+			files: files(
+				"db/V1.10__later.sql", "c",
+				"db/V1.9__add_users.sql", "b",
+				"db/V1__init.sql", "a",
+				"db/U1.9__add_users.sql", "undo b",
+				"db/R__refresh_views.sql", "r",
+				"db/README.md", "ignored",
+			),
+			want: []parsedMigration{
+				{key: "1", description: "init", up: "a"},
+				{key: "1.9", description: "add users", up: "b", down: "undo b"},
+				{key: "1.10", description: "later", up: "c"},
+				{key: "refresh_views", description: "refresh views", up: "r", repeatable: true},
+			},
+		},
+		{
+			name:   "flyway underscore separators and trailing zeros",
+			format: FormatFlyway,
+			files:  files("V2_1__a.sql", "x", "V3.0__b.sql", "y"),
+			want: []parsedMigration{
+				{key: "2.1", description: "a", up: "x"},
+				{key: "3", description: "b", up: "y"},
+			},
+		},
+		{
+			name:         "flyway skips other sql files",
+			format:       FormatFlyway,
+			files:        files("V1__a.sql", "x", "seed.sql", "y"),
+			want:         []parsedMigration{{key: "1", description: "a", up: "x"}},
+			wantWarnings: 1,
+		},
+		{
+			name:    "flyway duplicate version",
+			format:  FormatFlyway,
+			files:   files("V1__a.sql", "x", "V1.0__b.sql", "y"),
+			wantErr: true,
+		},
+		{
+			name:    "flyway undo without version",
+			format:  FormatFlyway,
+			files:   files("V1__a.sql", "x", "U2__b.sql", "y"),
+			wantErr: true,
+		},
+		{
+			name:   "golang-migrate pairs and repeatables",
+			format: FormatGolangMigrate,
+			files: files(
+				"migrations/10_add_index.up.sql", "c",
+				"migrations/2_create_users.up.sql", "a",
+				"migrations/2_create_users.down.sql", "undo a",
+				"migrations/repeatable/views.sql", "r",
+			),
+			want: []parsedMigration{
+				{key: "2", description: "create users", up: "a", down: "undo a"},
+				{key: "10", description: "add index", up: "c"},
+				{key: "views", description: "views", up: "r", repeatable: true},
+			},
+		},
+		{
+			name:    "golang-migrate down without up",
+			format:  FormatGolangMigrate,
+			files:   files("1_a.down.sql", "x"),
+			wantErr: true,
+		},
+		{
+			name:    "golang-migrate duplicate up",
+			format:  FormatGolangMigrate,
+			files:   files("1_a.up.sql", "x", "01_b.up.sql", "y"),
+			wantErr: true,
+		},
+		{
+			name:   "liquibase changesets",
+			format: FormatLiquibase,
+			files: files("changelog.sql", `-- liquibase formatted sql
+
+-- changeset alice:1
+-- comment: create users
+CREATE TABLE users (id int);
+-- rollback DROP TABLE users;
+
+-- changeset bob:2 runOnChange:true
+-- preconditions onFail:MARK_RAN
+-- precondition-sql-check expectedResult:0 SELECT 1
+ALTER TABLE users ADD name varchar(50);
+-- rollback not required
+`),
+			want: []parsedMigration{
+				{key: "alice:1", description: "create users", up: "CREATE TABLE users (id int);\n", down: "DROP TABLE users;\n"},
+				{key: "bob:2", up: "ALTER TABLE users ADD name varchar(50);\n"},
+			},
+		},
+		{
+			name:         "liquibase skips plain sql",
+			format:       FormatLiquibase,
+			files:        files("seed.sql", "INSERT INTO a VALUES (1);"),
+			want:         []parsedMigration{},
+			wantWarnings: 1,
+		},
+		{
+			name:    "liquibase duplicate changeset",
+			format:  FormatLiquibase,
+			files:   files("a.sql", "-- liquibase formatted sql\n-- changeset a:1\nSELECT 1;\n", "b.sql", "-- liquibase formatted sql\n-- changeset a:1\nSELECT 2;\n"),
+			wantErr: true,
+		},
+		{
+			name:    "unknown format",
+			format:  "sqitch",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, warnings, err := parseMigrations(tt.format, tt.files)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidOperation) {
+					t.Fatalf("parseMigrations() error = %v, want ErrInvalidOperation", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseMigrations() error: %v", err)
+			}
+			if got := parsed(migrations); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseMigrations() = %+v, want %+v", got, tt.want)
+			}
+			if len(warnings) != tt.wantWarnings {
+				t.Errorf("parseMigrations() warnings = %q, want %d", warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
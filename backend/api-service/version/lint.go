@@ -0,0 +1,435 @@
+package version
+
+import (
+	"backend/connectors"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Severities of a lint rule. Errors keep a version from being saved and from
+// being applied, warnings are only reported.
+const (
+	SeverityOff     = "off"
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+// Lint rules a project can configure.
+const (
+	RuleDropTable             = "drop_table"
+	RuleDropColumn            = "drop_column"
+	RuleDropConstraint        = "drop_constraint"
+	RuleTruncate              = "truncate"
+	RuleNarrowType            = "narrow_type"
+	RuleTypeChange            = "type_change"
+	RuleNotNullWithoutDefault = "not_null_without_default"
+	RuleIndexBuild            = "index_build"
+	RuleRename                = "rename"
+	RuleUnfilteredWrite       = "unfiltered_write"
+)
+
+var ErrLintFailed = errors.New("lint errors")
+
+// LintRules lists every rule with its default severity, in the order they are
+// reported.
+var LintRules = []LintRule{
+	{Rule: RuleDropTable, Description: "DROP TABLE, SCHEMA, DATABASE or VIEW", Default: SeverityWarning},
+	{Rule: RuleDropColumn, Description: "DROP COLUMN", Default: SeverityWarning},
+	{Rule: RuleDropConstraint, Description: "DROP CONSTRAINT", Default: SeverityWarning},
+	{Rule: RuleTruncate, Description: "TRUNCATE", Default: SeverityWarning},
+	{Rule: RuleNarrowType, Description: "column type changed to a bounded type like varchar(n), int or decimal(p,s)", Default: SeverityWarning},
+	{Rule: RuleTypeChange, Description: "column type changes that may rewrite the table", Default: SeverityWarning},
+	{Rule: RuleNotNullWithoutDefault, Description: "NOT NULL columns added without a default, or SET NOT NULL", Default: SeverityWarning},
+	{Rule: RuleIndexBuild, Description: "index builds that block writes, on Postgres without CONCURRENTLY", Default: SeverityWarning},
+	{Rule: RuleRename, Description: "renamed tables, columns and other objects", Default: SeverityWarning},
+	{Rule: RuleUnfilteredWrite, Description: "UPDATE or DELETE without WHERE", Default: SeverityWarning},
+}
+
+// boundedType matches the start of a type that holds less than the types it
+// usually replaces, the length of varchar(max) is not bounded.
+const boundedType = `(?:(?:TINYINT|SMALLINT|MEDIUMINT|INTEGER|INT2|INT4|INT)\b|N?(?:VAR)?CHAR(?:ACTER)?(?:\s+VARYING)?\s*\(\s*\d|DECIMAL\s*\(|NUMERIC\s*\()`
+
+type lintRule struct {
+	rule    string
+	kind    string
+	pattern *regexp.Regexp
+	// exclude suppresses the rule when it matches as well.
+	exclude *regexp.Regexp
+	message string
+	// dialects limits the rule to some engines, empty means all.
+	dialects []string
+}
+
+var lintRules = []lintRule{
+	{
+		rule:    RuleDropTable,
+		kind:    WarningDestructive,
+		pattern: regexp.MustCompile(`(?is)^\s*DROP\s+(TABLE|SCHEMA|DATABASE|VIEW|MATERIALIZED\s+VIEW)\b`),
+		message: "drops an object and its data",
+	},
+	{
+		rule:    RuleDropColumn,
+		kind:    WarningDestructive,
+		pattern: regexp.MustCompile(`(?is)\bDROP\s+COLUMN\b`),
+		message: "drops a column and its data",
+	},
+	{
+		rule:    RuleDropConstraint,
+		kind:    WarningDestructive,
+		pattern: regexp.MustCompile(`(?is)\bDROP\s+CONSTRAINT\b`),
+		message: "drops a constraint, the data is no longer checked",
+	},
+	{
+		rule:    RuleTruncate,
+		kind:    WarningDestructive,
+		pattern: regexp.MustCompile(`(?is)^\s*TRUNCATE\b`),
+		message: "removes all rows of a table",
+	},
+	{
+		rule:     RuleNarrowType,
+		kind:     WarningDestructive,
+		pattern:  regexp.MustCompile(`(?is)\bALTER\s+COLUMN\s+\S+\s+(SET\s+DATA\s+)?TYPE\s+` + boundedType),
+		message:  "changes a column to a bounded type, existing values may not fit",
+		dialects: []string{connectors.DialectPostgres},
+	},
+	{
+		rule:     RuleNarrowType,
+		kind:     WarningDestructive,
+		pattern:  regexp.MustCompile(`(?is)\b(MODIFY\s+(COLUMN\s+)?\S+|CHANGE\s+(COLUMN\s+)?\S+\s+\S+)\s+` + boundedType),
+		message:  "changes a column to a bounded type, existing values may not fit",
+		dialects: []string{connectors.DialectMySQL},
+	},
+	{
+		rule:     RuleNarrowType,
+		kind:     WarningDestructive,
+		pattern:  regexp.MustCompile(`(?is)\bALTER\s+COLUMN\s+\S+\s+` + boundedType),
+		message:  "changes a column to a bounded type, existing values may not fit",
+		dialects: []string{connectors.DialectMSSQL},
+	},
+	{
+		rule:    RuleTypeChange,
+		kind:    WarningTableRewrite,
+		pattern: regexp.MustCompile(`(?is)\bALTER\s+COLUMN\s+\S+\s+(SET\s+DATA\s+)?TYPE\b|\b(MODIFY|CHANGE)\s+(COLUMN\s+)?\S+`),
+		message: "changes a column type, which may rewrite the table",
+	},
+	{
+		rule:     RuleTypeChange,
+		kind:     WarningTableRewrite,
+		pattern:  regexp.MustCompile(`(?is)\bALTER\s+COLUMN\s+\S+\s+\S+`),
+		message:  "alters a column definition, which may rewrite the table",
+		dialects: []string{connectors.DialectMSSQL},
+	},
+	{
+		rule:    RuleNotNullWithoutDefault,
+		kind:    WarningTableRewrite,
+		pattern: regexp.MustCompile(`(?is)\bADD\s+(COLUMN\s+)?\S+\s+.*\bNOT\s+NULL\b`),
+		exclude: regexp.MustCompile(`(?i)\b(DEFAULT|IDENTITY|AUTO_INCREMENT|GENERATED)\b|\bADD\s+(CONSTRAINT|PRIMARY|UNIQUE|INDEX|KEY|FOREIGN|CHECK)\b`),
+		message: "adds a NOT NULL column without a default, this fails or rewrites the table when it has rows",
+	},
+	{
+		rule:     RuleNotNullWithoutDefault,
+		kind:     WarningTableRewrite,
+		pattern:  regexp.MustCompile(`(?is)\bALTER\s+COLUMN\s+\S+\s+SET\s+NOT\s+NULL\b`),
+		message:  "sets NOT NULL, which scans the table and fails when rows hold NULL",
+		dialects: []string{connectors.DialectPostgres},
+	},
+	{
+		rule:     RuleIndexBuild,
+		kind:     WarningIndexBuild,
+		pattern:  regexp.MustCompile(`(?is)^\s*CREATE\s+(UNIQUE\s+)?INDEX\b`),
+		exclude:  regexp.MustCompile(`(?i)\bCONCURRENTLY\b`),
+		message:  "builds an index without CONCURRENTLY and blocks writes to the table",
+		dialects: []string{connectors.DialectPostgres},
+	},
+	{
+		rule:     RuleIndexBuild,
+		kind:     WarningIndexBuild,
+		pattern:  regexp.MustCompile(`(?is)^\s*CREATE\s+(UNIQUE\s+)?(CLUSTERED\s+|NONCLUSTERED\s+)?INDEX\b`),
+		message:  "builds an index, this can take long on large tables",
+		dialects: []string{connectors.DialectMySQL, connectors.DialectMSSQL},
+	},
+	{
+		rule:    RuleRename,
+		kind:    WarningDestructive,
+		pattern: regexp.MustCompile(`(?is)\bRENAME\s+(TO|COLUMN|TABLE|INDEX|CONSTRAINT)\b|\bsp_rename\b`),
+		message: "renames an object, code that still uses the old name breaks",
+	},
+	{
+		rule:    RuleUnfilteredWrite,
+		kind:    WarningDestructive,
+		pattern: regexp.MustCompile(`(?is)^\s*(DELETE\s+FROM|UPDATE)\b`),
+		exclude: regexp.MustCompile(`(?i)\bWHERE\b`),
+		message: "changes every row, no WHERE clause",
+	},
+}
+
+// LintConfig maps rules to the severity a project configured for them. Rules
+// that are not in the map use their default.
+type LintConfig map[string]string
+
+func (c LintConfig) severity(rule string) string {
+	if severity, ok := c[rule]; ok {
+		return severity
+	}
+	for _, r := range LintRules {
+		if r.Rule == rule {
+			return r.Default
+		}
+	}
+	return SeverityOff
+}
+
+// lintStatements returns the findings for the statements of a script. Every
+// rule is reported at most once per statement.
+func lintStatements(config LintConfig, dialect string, statements []string) []PlanWarning {
+	findings := []PlanWarning{}
+
+	for i, statement := range statements {
+		seen := map[string]bool{}
+		for _, rule := range lintRules {
+			if len(rule.dialects) > 0 && !containsDialect(rule.dialects, dialect) {
+				continue
+			}
+			if seen[rule.rule] || !rule.pattern.MatchString(statement) {
+				continue
+			}
+			if rule.exclude != nil && rule.exclude.MatchString(statement) {
+				continue
+			}
+			severity := config.severity(rule.rule)
+			if severity == SeverityOff {
+				continue
+			}
+			seen[rule.rule] = true
+			findings = append(findings, PlanWarning{
+				Kind:      rule.kind,
+				Rule:      rule.rule,
+				Severity:  severity,
+				Statement: i + 1,
+				Message:   rule.message,
+			})
+		}
+	}
+
+	return findings
+}
+
+// lockWarnings returns the findings of the rules for index builds and table
+// rewrites that a project turned off. The plan reports locking operations
+// regardless of the lint severity, they come without rule and severity as
+// they do not count as lint findings.
+func lockWarnings(config LintConfig, dialect string, statements []string) []PlanWarning {
+	locking := LintConfig{}
+	for _, rule := range LintRules {
+		locking[rule.Rule] = SeverityOff
+	}
+	enabled := false
+	for _, rule := range lintRules {
+		if (rule.kind == WarningIndexBuild || rule.kind == WarningTableRewrite) && config.severity(rule.rule) == SeverityOff {
+			locking[rule.rule] = SeverityWarning
+			enabled = true
+		}
+	}
+	if !enabled {
+		return nil
+	}
+
+	findings := lintStatements(locking, dialect, statements)
+	for i := range findings {
+		findings[i].Rule, findings[i].Severity = "", ""
+	}
+	return findings
+}
+
+func containsDialect(dialects []string, dialect string) bool {
+	for _, d := range dialects {
+		if d == dialect {
+			return true
+		}
+	}
+	return false
+}
+
+// lintError returns ErrLintFailed with the first error of the findings, or nil
+// when there is none.
+func lintError(version string, findings []PlanWarning) error {
+	var errs []PlanWarning
+	for _, finding := range findings {
+		if finding.Severity == SeverityError {
+			errs = append(errs, finding)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+
+	first := errs[0]
+	if version != "" {
+		version += " "
+	}
+	return fmt.Errorf("%w: %d in %sup script, first: statement %d %s (%s)",
+		ErrLintFailed, len(errs), version, first.Statement, first.Message, first.Rule)
+}
+
+func validSeverity(severity string) bool {
+	switch severity {
+	case SeverityOff, SeverityWarning, SeverityError:
+		return true
+	}
+	return false
+}
+
+func knownRule(rule string) bool {
+	for _, r := range LintRules {
+		if r.Rule == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// GetLintConfig returns the rule severities a project changed from their
+// defaults.
+func (r *Repository) GetLintConfig(projectID int) (LintConfig, error) {
+	var rows []struct {
+		Rule     string `db:"rule"`
+		Severity string `db:"severity"`
+	}
+	if err := r.db.Select(&rows, `SELECT rule, severity FROM lint_rules WHERE project_id = $1`, projectID); err != nil {
+		return nil, err
+	}
+
+	config := LintConfig{}
+	for _, row := range rows {
+		config[row.Rule] = row.Severity
+	}
+	return config, nil
+}
+
+// GetLintRules returns every rule with the severity it has in a project.
+func (r *Repository) GetLintRules(projectID int) ([]LintRule, error) {
+	config, err := r.GetLintConfig(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]LintRule, len(LintRules))
+	for i, rule := range LintRules {
+		rule.Severity = config.severity(rule.Rule)
+		rules[i] = rule
+	}
+	return rules, nil
+}
+
+// UpdateLintRules sets the severity of the given rules in a project. Setting
+// a rule to its default removes the override.
+func (r *Repository) UpdateLintRules(projectID int, severities map[string]string) ([]LintRule, error) {
+	for rule, severity := range severities {
+		if !knownRule(rule) {
+			return nil, fmt.Errorf("%w: unknown lint rule %q", ErrInvalidOperation, rule)
+		}
+		if !validSeverity(severity) {
+			return nil, fmt.Errorf("%w: severity of %s must be off, warning or error", ErrInvalidOperation, rule)
+		}
+	}
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for rule, severity := range severities {
+		if severity == (LintConfig{}).severity(rule) {
+			_, err = tx.Exec(`DELETE FROM lint_rules WHERE project_id = $1 AND rule = $2`, projectID, rule)
+		} else {
+			_, err = tx.Exec(`
+				INSERT INTO lint_rules (project_id, rule, severity)
+				VALUES ($1, $2, $3)
+				ON CONFLICT (project_id, rule) DO UPDATE SET severity = EXCLUDED.severity`, projectID, rule, severity)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetLintRules(projectID)
+}
+
+// Lint checks an up script against the rules of a project.
+func (r *Repository) Lint(projectID int, dialect string, script string) ([]PlanWarning, error) {
+	config, err := r.GetLintConfig(projectID)
+	if err != nil {
+		return nil, err
+	}
+	return lintStatements(config, dialect, connectors.SplitStatementsFor(dialect, script)), nil
+}
+
+// SaveLint replaces the stored findings of a version.
+func (r *Repository) SaveLint(versionID int, findings []PlanWarning) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM version_lint WHERE version_id = $1`, versionID); err != nil {
+		return err
+	}
+	for _, finding := range findings {
+		_, err := tx.Exec(`
+			INSERT INTO version_lint (version_id, rule, kind, severity, statement, message)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			versionID, finding.Rule, finding.Kind, finding.Severity, finding.Statement, finding.Message)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *Repository) GetVersionLint(versionID int) ([]PlanWarning, error) {
+	findings := []PlanWarning{}
+	err := r.db.Select(&findings, `
+		SELECT rule, kind, severity, statement, message
+		FROM version_lint
+		WHERE version_id = $1
+		ORDER BY statement, id`, versionID)
+	return findings, err
+}
+
+// checkLint lints the versions about to run with the current rules of the
+// project and stores the findings. Rules and scripts may have changed since a
+// version was saved, so errors are checked again before anything runs.
+func (m *Migrator) checkLint(versions []Version) error {
+	config, err := m.repo.GetLintConfig(m.projectID)
+	if err != nil {
+		return err
+	}
+
+	var blocked []string
+	var first error
+	for _, v := range versions {
+		findings := lintStatements(config, m.conn.Dialect(), m.conn.SplitStatements(v.Up.Script))
+		if err := m.repo.SaveLint(v.Id, findings); err != nil {
+			return err
+		}
+		if err := lintError(v.Version, findings); err != nil {
+			blocked = append(blocked, v.Version)
+			if first == nil {
+				first = err
+			}
+		}
+	}
+
+	if first != nil {
+		return fmt.Errorf("%w, versions with errors: %s", first, strings.Join(blocked, ", "))
+	}
+	return nil
+}
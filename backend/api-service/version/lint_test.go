@@ -0,0 +1,94 @@
+package version
+
+import (
+	"backend/connectors"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLintStatements(t *testing.T) {
+	tests := []struct {
+		name      string
+		dialect   string
+		statement string
+		want      []string
+	}{
+		{"drop table", connectors.DialectPostgres, "DROP TABLE users", []string{RuleDropTable}},
+		{"drop materialized view", connectors.DialectPostgres, "drop materialized view totals", []string{RuleDropTable}},
+		{"drop column", connectors.DialectMySQL, "ALTER TABLE users DROP COLUMN name", []string{RuleDropColumn}},
+		{"drop constraint", connectors.DialectMSSQL, "ALTER TABLE users DROP CONSTRAINT fk_role", []string{RuleDropConstraint}},
+		{"truncate", connectors.DialectPostgres, "TRUNCATE users", []string{RuleTruncate}},
+		{"postgres narrowing", connectors.DialectPostgres, "ALTER TABLE users ALTER COLUMN name TYPE varchar(20)", []string{RuleNarrowType, RuleTypeChange}},
+		{"postgres widening", connectors.DialectPostgres, "ALTER TABLE users ALTER COLUMN name TYPE text", []string{RuleTypeChange}},
+		{"mysql narrowing", connectors.DialectMySQL, "ALTER TABLE users MODIFY COLUMN age smallint", []string{RuleNarrowType, RuleTypeChange}},
+		{"mssql narrowing", connectors.DialectMSSQL, "ALTER TABLE users ALTER COLUMN name nvarchar(20)", []string{RuleNarrowType, RuleTypeChange}},
+		{"mssql varchar max", connectors.DialectMSSQL, "ALTER TABLE users ALTER COLUMN name nvarchar(max)", []string{RuleTypeChange}},
+		{"not null without default", connectors.DialectPostgres, "ALTER TABLE users ADD COLUMN age int NOT NULL", []string{RuleNotNullWithoutDefault}},
+		{"not null with default", connectors.DialectPostgres, "ALTER TABLE users ADD COLUMN age int NOT NULL DEFAULT 0", nil},
+		{"set not null", connectors.DialectPostgres, "ALTER TABLE users ALTER COLUMN age SET NOT NULL", []string{RuleNotNullWithoutDefault}},
+		{"postgres index", connectors.DialectPostgres, "CREATE UNIQUE INDEX ix ON users (email)", []string{RuleIndexBuild}},
+		{"postgres concurrent index", connectors.DialectPostgres, "CREATE INDEX CONCURRENTLY ix ON users (email)", nil},
+		{"mssql index", connectors.DialectMSSQL, "CREATE NONCLUSTERED INDEX ix ON users (email)", []string{RuleIndexBuild}},
+		{"rename table", connectors.DialectPostgres, "ALTER TABLE users RENAME TO members", []string{RuleRename}},
+		{"sp_rename", connectors.DialectMSSQL, "EXEC sp_rename 'users.name', 'full_name', 'COLUMN'", []string{RuleRename}},
+		{"unfiltered delete", connectors.DialectPostgres, "DELETE FROM users", []string{RuleUnfilteredWrite}},
+		{"filtered update", connectors.DialectPostgres, "UPDATE users SET active = true WHERE id = 1", nil},
+		{"create table", connectors.DialectPostgres, "CREATE TABLE users (id int NOT NULL)", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, finding := range lintStatements(LintConfig{}, tt.dialect, []string{tt.statement}) {
+				got = append(got, finding.Rule)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lintStatements(%q) rules = %q, want %q", tt.statement, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintStatementsConfig(t *testing.T) {
+	config := LintConfig{RuleDropTable: SeverityError, RuleTruncate: SeverityOff}
+	statements := []string{"TRUNCATE users", "DROP TABLE users", "ALTER TABLE roles DROP COLUMN name"}
+
+	findings := lintStatements(config, connectors.DialectPostgres, statements)
+	if len(findings) != 2 {
+		t.Fatalf("lintStatements() = %+v, want 2 findings", findings)
+	}
+	if findings[0].Rule != RuleDropTable || findings[0].Severity != SeverityError || findings[0].Statement != 2 {
+		t.Errorf("first finding = %+v, want drop_table error on statement 2", findings[0])
+	}
+	if findings[1].Rule != RuleDropColumn || findings[1].Severity != SeverityWarning || findings[1].Statement != 3 {
+		t.Errorf("second finding = %+v, want drop_column warning on statement 3", findings[1])
+	}
+
+	if err := lintError("v1.0.0", findings); !errors.Is(err, ErrLintFailed) {
+		t.Errorf("lintError() = %v, want ErrLintFailed", err)
+	}
+	if err := lintError("v1.0.0", findings[1:]); err != nil {
+		t.Errorf("lintError() of warnings = %v, want nil", err)
+	}
+}
+
+func TestLockWarnings(t *testing.T) {
+	statements := []string{"CREATE INDEX ix ON users (email)", "ALTER TABLE users ALTER COLUMN name TYPE text", "DROP TABLE roles"}
+
+	if got := lockWarnings(LintConfig{}, connectors.DialectPostgres, statements); len(got) != 0 {
+		t.Errorf("lockWarnings() with lint rules on = %+v, want none, lint reports them", got)
+	}
+
+	config := LintConfig{RuleIndexBuild: SeverityOff, RuleTypeChange: SeverityOff, RuleDropTable: SeverityOff}
+	var kinds []string
+	for _, warning := range lockWarnings(config, connectors.DialectPostgres, statements) {
+		if warning.Rule != "" || warning.Severity != "" {
+			t.Errorf("lock warning %+v has a rule or severity", warning)
+		}
+		kinds = append(kinds, warning.Kind)
+	}
+	if want := []string{WarningIndexBuild, WarningTableRewrite}; !reflect.DeepEqual(kinds, want) {
+		t.Errorf("lockWarnings() kinds = %q, want %q", kinds, want)
+	}
+}
@@ -0,0 +1,149 @@
+package version
+
+import (
+	"backend/connectors"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// metadataLockNamespace is the first key of the two-key advisory lock taken in
+// the metadata database; the second key is the project id.
+const metadataLockNamespace = 7301
+
+const DefaultLockTimeout = 30 * time.Second
+
+var ErrLocked = errors.New("project is locked by another migration")
+
+// lock serializes apply and rollback per project. It takes an advisory lock in
+// the metadata database, which covers every API replica, records the holder in
+// migration_locks and then takes the engine-native lock on the target. The
+// returned function releases everything in reverse order.
+func (m *Migrator) lock(ctx context.Context, target *sql.Conn, operation string) (func(), error) {
+	meta, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(m.LockTimeout)
+	for {
+		var locked bool
+		err := meta.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1, $2)`, metadataLockNamespace, m.projectID).Scan(&locked)
+		if err != nil {
+			meta.Close()
+			return nil, err
+		}
+		if locked {
+			break
+		}
+		if time.Now().After(deadline) {
+			meta.Close()
+			return nil, m.lockedError()
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+
+	unlockMeta := func() {
+		meta.ExecContext(ctx, `DELETE FROM migration_locks WHERE project_id = $1 AND backend_pid = pg_backend_pid()`, m.projectID)
+		meta.ExecContext(ctx, `SELECT pg_advisory_unlock($1, $2)`, metadataLockNamespace, m.projectID)
+		meta.Close()
+	}
+
+	hostname, _ := os.Hostname()
+	_, err = meta.ExecContext(ctx, `
+		INSERT INTO migration_locks (project_id, holder_id, operation, hostname, backend_pid, acquired_at)
+		VALUES ($1, $2, $3, $4, pg_backend_pid(), NOW())
+		ON CONFLICT (project_id) DO UPDATE
+		SET holder_id = EXCLUDED.holder_id,
+		    operation = EXCLUDED.operation,
+		    hostname = EXCLUDED.hostname,
+		    backend_pid = EXCLUDED.backend_pid,
+		    acquired_at = EXCLUDED.acquired_at`,
+		m.projectID, m.userID, operation, hostname)
+	if err != nil {
+		unlockMeta()
+		return nil, err
+	}
+
+	if err := m.conn.AcquireMigrationLock(ctx, target, m.LockTimeout); err != nil {
+		unlockMeta()
+		if errors.Is(err, connectors.ErrLockTimeout) {
+			return nil, fmt.Errorf("%w: target database is locked by another migration", ErrLocked)
+		}
+		return nil, err
+	}
+
+	return func() {
+		m.conn.ReleaseMigrationLock(ctx, target)
+		unlockMeta()
+	}, nil
+}
+
+func (m *Migrator) lockedError() error {
+	holder, err := m.repo.GetMigrationLock(m.projectID)
+	if err != nil || holder == nil {
+		return ErrLocked
+	}
+	return fmt.Errorf("%w: %s by user %d on %s since %s", ErrLocked,
+		holder.Operation, holder.HolderID, holder.Hostname, holder.AcquiredAt.Format(time.RFC3339))
+}
+
+// GetMigrationLock returns the current lock holder of a project or nil. A row
+// whose advisory lock is no longer held is reported as inactive.
+func (r *Repository) GetMigrationLock(projectID int) (*MigrationLock, error) {
+	stmt, err := r.db.PrepareNamed(`
+		SELECT l.project_id, l.holder_id, l.operation, l.hostname, l.backend_pid, l.acquired_at,
+		       EXISTS (
+		           SELECT 1 FROM pg_locks pl
+		           WHERE pl.locktype = 'advisory' AND pl.granted
+		             AND pl.classid = :namespace AND pl.objid = l.project_id AND pl.objsubid = 2
+		             AND pl.pid = l.backend_pid
+		       ) AS active
+		FROM migration_locks l
+		WHERE l.project_id = :projectId`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var lock MigrationLock
+	err = stmt.Get(&lock, map[string]any{"projectId": projectID, "namespace": metadataLockNamespace})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &lock, nil
+}
+
+// ForceUnlock removes the lock of a project. When the holder session still
+// holds the advisory lock it is terminated, which releases the lock.
+func (r *Repository) ForceUnlock(projectID int) (*MigrationLock, error) {
+	lock, err := r.GetMigrationLock(projectID)
+	if err != nil || lock == nil {
+		return nil, err
+	}
+
+	if lock.Active {
+		if _, err := r.db.Exec(`SELECT pg_terminate_backend($1)`, lock.BackendPID); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := r.db.Exec(`DELETE FROM migration_locks WHERE project_id = $1`, projectID); err != nil {
+		return nil, err
+	}
+
+	return lock, nil
+}
+
+func (r *Repository) GetProjectOwner(projectID int) (int, error) {
+	var ownerID int
+	err := r.db.Get(&ownerID, `SELECT owner_id FROM projects WHERE id = $1`, projectID)
+	return ownerID, err
+}
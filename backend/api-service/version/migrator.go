@@ -0,0 +1,689 @@
+package version
+
+import (
+	"backend/connectors"
+	"backend/snapshot"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrVersionNotFound   = errors.New("version not found")
+	ErrVersionNotPending = errors.New("only pending versions can be changed")
+	ErrInvalidTarget     = errors.New("invalid rollback target")
+	ErrMissingDownScript = errors.New("versions without down script, use force to roll back anyway")
+	ErrValidationFailed  = errors.New("version validation failed")
+)
+
+// Migrator runs version scripts of one project against its target database and
+// records the outcome in the metadata database.
+type Migrator struct {
+	// LockTimeout is how long Apply and Rollback wait for the project lock.
+	LockTimeout time.Duration
+	// TransactionMode decides whether scripts run inside transactions.
+	TransactionMode string
+
+	db        *sqlx.DB
+	repo      *Repository
+	snapshots *snapshot.Repository
+	conn      connectors.DBConnector
+	projectID int
+	userID    int
+}
+
+func NewMigrator(db *sqlx.DB, conn connectors.DBConnector, projectID int, userID int) *Migrator {
+	return &Migrator{
+		LockTimeout:     DefaultLockTimeout,
+		TransactionMode: TransactionPerVersion,
+		db:              db,
+		repo:            NewRepositoryFromDb(db),
+		snapshots:       snapshot.NewRepositoryFromDb(db),
+		conn:            conn,
+		projectID:       projectID,
+		userID:          userID,
+	}
+}
+
+// Apply runs every pending or previously failed version in order and stops at
+// the first failure so later versions never run on top of a broken one.
+func (m *Migrator) Apply() (*ApplyResponse, error) {
+	ctx := context.Background()
+	db, target, err := m.openTarget(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	defer target.Close()
+
+	unlock, err := m.lock(ctx, target, connectors.HistoryOperationApply)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	reconciled, err := m.reconcile(ctx, target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile with %s: %w", connectors.HistoryTableName, err)
+	}
+
+	validation, err := m.repo.Validate(m.projectID)
+	if err != nil {
+		return nil, err
+	}
+	if !validation.Valid {
+		return nil, validationError(validation.Issues)
+	}
+
+	versions, err := m.repo.GetVersionsToApply(m.projectID)
+	if err != nil {
+		return nil, err
+	}
+	repeatables, err := m.repo.GetRepeatablesToApply(m.projectID)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.checkLint(append(append([]Version{}, versions...), repeatables...)); err != nil {
+		return nil, err
+	}
+
+	mode, warnings := m.transactionMode(versions)
+	response := &ApplyResponse{
+		ProjectID:       m.projectID,
+		Success:         true,
+		TransactionMode: mode,
+		Warnings:        warnings,
+		Reconciled:      reconciled,
+		Versions:        []VersionRunResult{},
+	}
+
+	var results []VersionRunResult
+	if response.TransactionMode == TransactionBatch {
+		// The batch is committed as a whole, so only the last version sees
+		// the schema it leaves behind.
+		results = m.applyBatch(ctx, target, versions)
+		for i := range results {
+			last := i == len(results)-1
+			if err := m.recordApply(versions[i], &results[i], last); err != nil {
+				return nil, err
+			}
+			if !last && results[i].State == StateCompleted {
+				results[i].Warnings = append(results[i].Warnings, "applied in one transaction with later versions, no schema snapshot of its own")
+			}
+		}
+	} else {
+		results, err = applyInOrder(versions,
+			func(v Version) VersionRunResult {
+				return m.execute(ctx, target, v, v.Up, connectors.HistoryOperationApply, response.TransactionMode != TransactionNone)
+			},
+			func(v Version, result *VersionRunResult) error {
+				return m.recordApply(v, result, true)
+			})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, result := range results {
+		if result.State == StateCompleted {
+			response.Applied++
+		} else {
+			response.Success = false
+		}
+		response.Versions = append(response.Versions, result)
+	}
+
+	if response.Success {
+		if err := m.applyRepeatables(ctx, target, repeatables, response); err != nil {
+			return nil, err
+		}
+	}
+
+	return response, nil
+}
+
+// applyInOrder runs versions one at a time and records each before the next
+// one runs, so its state and snapshot reflect the schema right after it. It
+// stops at the first failure.
+func applyInOrder(versions []Version, run func(Version) VersionRunResult, record func(Version, *VersionRunResult) error) ([]VersionRunResult, error) {
+	var results []VersionRunResult
+	for _, v := range versions {
+		result := run(v)
+		if err := record(v, &result); err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+		if result.State != StateCompleted {
+			break
+		}
+	}
+	return results, nil
+}
+
+// recordApply stores the outcome of an applied version in the metadata
+// database. With capture set the schema is snapshotted, which must happen
+// before anything else runs on the target.
+func (m *Migrator) recordApply(v Version, result *VersionRunResult, capture bool) error {
+	if result.State == "" {
+		result.State = StateCompleted
+		if result.Error != "" {
+			result.State = StateFailed
+		}
+	}
+	if result.State == StatePending {
+		return nil
+	}
+
+	if err := m.repo.SetVersionState(v.Id, result.State); err != nil {
+		return err
+	}
+	if err := m.repo.CreateAudit(v.Id, m.userID, auditNote("apply", *result)); err != nil {
+		return err
+	}
+	if result.State != StateCompleted {
+		return nil
+	}
+	if err := m.repo.SetAppliedChecksum(v.Id, v.Up.Checksum()); err != nil {
+		return err
+	}
+	if capture {
+		m.captureSnapshot(v.Id, result)
+	}
+	return nil
+}
+
+// applyRepeatables runs the repeatable versions that are new or changed once
+// all versioned ones succeeded, each in a transaction of its own unless
+// transactions are off. Like versions they stop at the first failure.
+func (m *Migrator) applyRepeatables(ctx context.Context, target *sql.Conn, repeatables []Version, response *ApplyResponse) error {
+	for _, v := range repeatables {
+		result := m.execute(ctx, target, v, v.Up, connectors.HistoryOperationApply, response.TransactionMode != TransactionNone)
+		result.State = StateCompleted
+		if result.Error != "" {
+			result.State = StateFailed
+		}
+
+		if err := m.repo.SetVersionState(v.Id, result.State); err != nil {
+			return err
+		}
+		if err := m.repo.CreateAudit(v.Id, m.userID, auditNote("apply", result)); err != nil {
+			return err
+		}
+		response.Versions = append(response.Versions, result)
+
+		if result.State != StateCompleted {
+			response.Success = false
+			return nil
+		}
+		if err := m.repo.SetAppliedChecksum(v.Id, v.Up.Checksum()); err != nil {
+			return err
+		}
+		response.Applied++
+	}
+
+	return nil
+}
+
+// applyBatch runs all versions in a single transaction. When one fails the
+// whole batch is rolled back and the versions before it stay pending.
+func (m *Migrator) applyBatch(ctx context.Context, target *sql.Conn, versions []Version) []VersionRunResult {
+	var results []VersionRunResult
+	if len(versions) == 0 {
+		return results
+	}
+
+	tx, err := target.BeginTx(ctx, nil)
+	if err != nil {
+		return []VersionRunResult{failedResult(versions[0], "failed to begin transaction: "+err.Error())}
+	}
+
+	failed := false
+	for _, v := range versions {
+		result := m.run(ctx, tx, v, v.Up)
+		result.Transactional = true
+		if result.Error == "" {
+			m.recordHistory(ctx, tx, v, connectors.HistoryOperationApply, v.Up, &result)
+		}
+		results = append(results, result)
+		if result.Error != "" {
+			failed = true
+			break
+		}
+	}
+
+	if !failed {
+		err := tx.Commit()
+		if err == nil {
+			return results
+		}
+		results[len(results)-1].Error = "failed to commit transaction: " + err.Error()
+	} else {
+		tx.Rollback()
+	}
+
+	last := len(results) - 1
+	for i := range results[:last] {
+		results[i].State = StatePending
+		results[i].Warnings = append(results[i].Warnings, "rolled back together with the failed batch")
+	}
+	results[last].Warnings = append(results[last].Warnings, "transaction rolled back, no changes of this batch were kept")
+	m.recordHistory(ctx, target, versions[last], connectors.HistoryOperationApply, versions[last].Up, &results[last])
+
+	return results
+}
+
+// Rollback runs the down scripts of every completed version after the target
+// in reverse order. A target of 0 rolls back all completed versions. Versions
+// without a down script block the rollback unless force is set, in which case
+// they are only marked as rolled back. Repeatable versions stay as they are.
+func (m *Migrator) Rollback(targetVersionID int, force bool) (*RollbackResponse, error) {
+	ctx := context.Background()
+	db, target, err := m.openTarget(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	defer target.Close()
+
+	unlock, err := m.lock(ctx, target, connectors.HistoryOperationRollback)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if _, err := m.reconcile(ctx, target); err != nil {
+		return nil, fmt.Errorf("failed to reconcile with %s: %w", connectors.HistoryTableName, err)
+	}
+
+	if targetVersionID != 0 {
+		targetVersion, err := m.repo.GetVersion(m.projectID, targetVersionID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, ErrVersionNotFound
+			}
+			return nil, err
+		}
+		if targetVersion.Kind == KindRepeatable {
+			return nil, fmt.Errorf("%w: %s is a repeatable version", ErrInvalidTarget, targetVersion.Version)
+		}
+		if targetVersion.State != StateCompleted {
+			return nil, fmt.Errorf("%w: target version %d is %s", ErrInvalidTarget, targetVersion.Id, targetVersion.State)
+		}
+	}
+
+	versions, err := m.repo.GetVersionsToRollback(m.projectID, targetVersionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for _, v := range versions {
+		if strings.TrimSpace(v.Down.Script) == "" {
+			missing = append(missing, fmt.Sprintf("%d (%s)", v.Id, v.Version))
+		}
+	}
+	if len(missing) > 0 && !force {
+		return nil, fmt.Errorf("%w: %s", ErrMissingDownScript, strings.Join(missing, ", "))
+	}
+
+	response := &RollbackResponse{
+		ProjectID:       m.projectID,
+		TargetVersionID: targetVersionID,
+		Success:         true,
+		Versions:        []VersionRunResult{},
+	}
+
+	for _, v := range versions {
+		result := m.execute(ctx, target, v, v.Down, connectors.HistoryOperationRollback, m.TransactionMode != TransactionNone)
+		if strings.TrimSpace(v.Down.Script) == "" {
+			result.Warnings = append(result.Warnings, "no down script, version marked as rolled back without changes")
+		}
+
+		state := StateRolledBack
+		if result.Error != "" {
+			state = StateCompleted
+			response.Success = false
+		}
+		result.State = state
+
+		if state == StateRolledBack {
+			if err := m.repo.SetVersionState(v.Id, state); err != nil {
+				return nil, err
+			}
+			response.RolledBack++
+		}
+		if err := m.repo.CreateAudit(v.Id, m.userID, auditNote("rollback", result)); err != nil {
+			return nil, err
+		}
+
+		response.Versions = append(response.Versions, result)
+
+		if !response.Success {
+			return response, nil
+		}
+	}
+
+	if targetVersionID != 0 && len(response.Versions) > 0 {
+		m.captureSnapshot(targetVersionID, &response.Versions[len(response.Versions)-1])
+	}
+
+	return response, nil
+}
+
+// reconcile brings the version states in line with the history table of the
+// target database, which wins when the two disagree: a restored database or a
+// second ChronoDB instance may have applied or lost versions. When the history
+// table is created for the first time it is seeded from the completed versions.
+func (m *Migrator) reconcile(ctx context.Context, target *sql.Conn) ([]ReconcileChange, error) {
+	created, err := m.conn.EnsureHistoryTable(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := m.repo.GetVersions(m.projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if created {
+		for _, v := range versions {
+			if v.State != StateCompleted {
+				continue
+			}
+			entry := connectors.HistoryEntry{
+				VersionID: v.Id,
+				Version:   v.Version,
+				Operation: connectors.HistoryOperationApply,
+				Checksum:  v.Up.Checksum(),
+				Success:   true,
+			}
+			if v.AppliedChecksum != nil {
+				entry.Checksum = *v.AppliedChecksum
+			}
+			if v.AppliedAt != nil {
+				entry.AppliedAt = v.AppliedAt.UTC()
+			}
+			if err := m.conn.RecordHistory(ctx, target, entry); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	}
+
+	history, err := m.conn.ReadHistory(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := reconcileChanges(versions, history)
+	for _, change := range changes {
+		if err := m.repo.SetVersionState(change.VersionID, change.To); err != nil {
+			return nil, err
+		}
+		if change.To == StateCompleted {
+			// Keep the checksum of what the target really ran, so validation
+			// catches a script that differs from it.
+			if err := m.repo.SetAppliedChecksum(change.VersionID, change.AppliedChecksum); err != nil {
+				return nil, err
+			}
+		}
+		note := fmt.Sprintf("reconcile %s: %s -> %s from %s", change.Version, change.From, change.To, connectors.HistoryTableName)
+		if err := m.repo.CreateAudit(change.VersionID, m.userID, note); err != nil {
+			return nil, err
+		}
+	}
+
+	return changes, nil
+}
+
+// reconcileChanges computes the state corrections implied by the history
+// table. Versions are matched by label, which is unique per project and the
+// same for every ChronoDB instance that imported them. The last successful
+// operation decides whether a version is applied.
+func reconcileChanges(versions []Version, history []connectors.HistoryEntry) []ReconcileChange {
+	applied := map[string]bool{}
+	checksums := map[string]string{}
+	for _, entry := range history {
+		if entry.Success {
+			applied[entry.Version] = entry.Operation == connectors.HistoryOperationApply
+			checksums[entry.Version] = entry.Checksum
+		}
+	}
+
+	var changes []ReconcileChange
+	for _, v := range versions {
+		isApplied, known := applied[v.Version]
+
+		state := v.State
+		switch {
+		case isApplied && v.State != StateCompleted:
+			state = StateCompleted
+		case !isApplied && v.State == StateCompleted && known:
+			state = StateRolledBack
+		case !isApplied && v.State == StateCompleted:
+			state = StatePending
+		}
+		if state == v.State {
+			continue
+		}
+
+		change := ReconcileChange{VersionID: v.Id, Version: v.Version, From: v.State, To: state}
+		if state == StateCompleted {
+			change.AppliedChecksum = checksums[v.Version]
+		}
+		changes = append(changes, change)
+	}
+
+	return changes
+}
+
+// withChanges returns a copy of the versions with reconcile changes applied.
+func withChanges(versions []Version, changes []ReconcileChange) []Version {
+	byID := map[int]ReconcileChange{}
+	for _, change := range changes {
+		byID[change.VersionID] = change
+	}
+
+	result := make([]Version, len(versions))
+	for i, v := range versions {
+		if change, ok := byID[v.Id]; ok {
+			v.State = change.To
+			if change.To == StateCompleted {
+				checksum := change.AppliedChecksum
+				v.AppliedChecksum = &checksum
+			} else {
+				v.AppliedChecksum = nil
+			}
+		}
+		result[i] = v
+	}
+	return result
+}
+
+// transactionMode resolves the requested mode for a set of versions. A batch
+// falls back to one transaction per version when any of them cannot run in a
+// transaction.
+func (m *Migrator) transactionMode(versions []Version) (string, []string) {
+	if m.TransactionMode != TransactionBatch {
+		return m.TransactionMode, nil
+	}
+
+	for _, v := range versions {
+		if !m.transactional(v.Up) {
+			return TransactionPerVersion, []string{fmt.Sprintf(
+				"version %d (%s) cannot run inside a transaction, versions are applied one transaction each", v.Id, v.Version)}
+		}
+	}
+	return TransactionBatch, nil
+}
+
+// recordHistory writes the outcome of a run to the target history table. The
+// run itself already happened, so a failure here is reported as a warning.
+func (m *Migrator) recordHistory(ctx context.Context, target connectors.Session, v Version, operation string, script SQLScript, result *VersionRunResult) {
+	err := m.conn.RecordHistory(ctx, target, connectors.HistoryEntry{
+		VersionID:  v.Id,
+		Version:    v.Version,
+		Operation:  operation,
+		Checksum:   script.Checksum(),
+		AppliedBy:  m.userID,
+		DurationMs: result.DurationMs,
+		Success:    result.Error == "",
+	})
+	if err != nil {
+		result.Warnings = append(result.Warnings, "failed to record "+connectors.HistoryTableName+": "+err.Error())
+	}
+}
+
+// openTarget connects to the target database and reserves a single connection
+// so every statement of a run shares the same session.
+func (m *Migrator) openTarget(ctx context.Context) (*sql.DB, *sql.Conn, error) {
+	db, err := m.conn.Open(m.projectID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to target database: %w", err)
+	}
+
+	target, err := db.Conn(ctx)
+	if err != nil {
+		db.Close()
+		return nil, nil, fmt.Errorf("failed to connect to target database: %w", err)
+	}
+
+	return db, target, nil
+}
+
+// execute runs one script and records it in the history table. Unless
+// useTransaction is false or a statement cannot run in a transaction, the
+// script and its history entry are committed together or not at all.
+func (m *Migrator) execute(ctx context.Context, target *sql.Conn, v Version, script SQLScript, operation string, useTransaction bool) VersionRunResult {
+	if !useTransaction || !m.transactional(script) {
+		result := m.run(ctx, target, v, script)
+		if result.FailedStatement > 1 {
+			result.PartiallyApplied = true
+			result.Warnings = append(result.Warnings, fmt.Sprintf(
+				"not run in a transaction, statements 1 to %d stay applied", result.FailedStatement-1))
+		}
+		m.recordHistory(ctx, target, v, operation, script, &result)
+		return result
+	}
+
+	tx, err := target.BeginTx(ctx, nil)
+	if err != nil {
+		return failedResult(v, "failed to begin transaction: "+err.Error())
+	}
+
+	result := m.run(ctx, tx, v, script)
+	result.Transactional = true
+
+	if result.Error == "" {
+		m.recordHistory(ctx, tx, v, operation, script, &result)
+		err := tx.Commit()
+		if err == nil {
+			return result
+		}
+		result.Error = "failed to commit transaction: " + err.Error()
+	} else {
+		tx.Rollback()
+	}
+
+	result.Warnings = append(result.Warnings, "transaction rolled back, no changes were kept")
+	m.recordHistory(ctx, target, v, operation, script, &result)
+	return result
+}
+
+// transactional reports whether every statement of the script can run inside
+// a transaction on the target engine.
+func (m *Migrator) transactional(script SQLScript) bool {
+	for _, statement := range m.conn.SplitStatements(script.Script) {
+		if !m.conn.SupportsTransaction(statement) {
+			return false
+		}
+	}
+	return true
+}
+
+func failedResult(v Version, message string) VersionRunResult {
+	return VersionRunResult{
+		VersionID: v.Id,
+		Version:   v.Version,
+		Kind:      v.Kind,
+		Output:    []StatementOutput{},
+		Error:     message,
+	}
+}
+
+// run executes the statements of a script one by one and collects their output.
+func (m *Migrator) run(ctx context.Context, target connectors.Session, v Version, script SQLScript) VersionRunResult {
+	result := VersionRunResult{
+		VersionID: v.Id,
+		Version:   v.Version,
+		Kind:      v.Kind,
+		Output:    []StatementOutput{},
+	}
+
+	start := time.Now()
+
+	for i, statement := range m.conn.SplitStatements(script.Script) {
+		output := StatementOutput{Index: i + 1, Statement: statement}
+
+		res, err := target.ExecContext(ctx, statement)
+		if err != nil {
+			output.Error = err.Error()
+			result.Output = append(result.Output, output)
+			result.FailedStatement = i + 1
+			result.Error = fmt.Sprintf("statement %d failed: %s", i+1, err.Error())
+			result.DurationMs = time.Since(start).Milliseconds()
+			return result
+		}
+
+		if affected, err := res.RowsAffected(); err == nil {
+			output.RowsAffected = affected
+		}
+		result.Output = append(result.Output, output)
+	}
+
+	result.DurationMs = time.Since(start).Milliseconds()
+	return result
+}
+
+// captureSnapshot records the schema after a version was applied. A failing
+// capture is reported but does not undo the already applied version.
+func (m *Migrator) captureSnapshot(versionID int, result *VersionRunResult) {
+	versionRef, userRef := versionID, m.userID
+	captured, err := m.snapshots.Capture(m.conn, snapshot.CaptureRequest{
+		ProjectID: m.projectID,
+		VersionID: &versionRef,
+		UserID:    &userRef,
+		Source:    snapshot.SourceVersion,
+	})
+	if err != nil {
+		result.Warnings = append(result.Warnings, "failed to capture schema snapshot: "+err.Error())
+		return
+	}
+	result.SnapshotID = &captured.Snapshot.ID
+}
+
+func auditNote(action string, result VersionRunResult) string {
+	note := fmt.Sprintf("%s %s: %s in %dms", action, result.Version, result.State, result.DurationMs)
+	if result.Error != "" {
+		note += ": " + result.Error
+	}
+	if result.PartiallyApplied {
+		note += " (not transactional, earlier statements stay applied)"
+	}
+	if len(note) > 512 {
+		// Cut on a character boundary, a split UTF-8 sequence is rejected by
+		// the database.
+		cut := 509
+		for cut > 0 && !utf8.RuneStart(note[cut]) {
+			cut--
+		}
+		note = note[:cut] + "..."
+	}
+	return note
+}
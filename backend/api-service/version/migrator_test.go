@@ -0,0 +1,90 @@
+package version
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeTarget is a schema that versions change by adding the tables named in
+// their up script, one name per line.
+type fakeTarget struct {
+	tables []string
+}
+
+func (f *fakeTarget) run(v Version) VersionRunResult {
+	result := VersionRunResult{VersionID: v.Id, Version: v.Version}
+	for _, table := range strings.Split(v.Up.Script, "\n") {
+		if table == "fail" {
+			result.Error = "failed"
+			return result
+		}
+		f.tables = append(f.tables, table)
+	}
+	return result
+}
+
+func TestApplyInOrderSnapshots(t *testing.T) {
+	versions := []Version{
+		{Id: 1, Version: "v1.0.0", Up: NewScript("users")},
+		{Id: 2, Version: "v1.1.0", Up: NewScript("roles")},
+		{Id: 3, Version: "v1.2.0", Up: NewScript("grants\nfail")},
+		{Id: 4, Version: "v1.3.0", Up: NewScript("audit")},
+	}
+
+	target := &fakeTarget{}
+	snapshots := map[int][]string{}
+	record := func(v Version, result *VersionRunResult) error {
+		if result.Error != "" {
+			result.State = StateFailed
+			return nil
+		}
+		result.State = StateCompleted
+		snapshots[v.Id] = append([]string{}, target.tables...)
+		return nil
+	}
+
+	results, err := applyInOrder(versions, target.run, record)
+	if err != nil {
+		t.Fatalf("applyInOrder() error: %v", err)
+	}
+
+	var states []string
+	for _, result := range results {
+		states = append(states, result.State)
+	}
+	if want := []string{StateCompleted, StateCompleted, StateFailed}; !reflect.DeepEqual(states, want) {
+		t.Errorf("states = %q, want %q", states, want)
+	}
+
+	want := map[int][]string{
+		1: {"users"},
+		2: {"users", "roles"},
+	}
+	if !reflect.DeepEqual(snapshots, want) {
+		t.Errorf("snapshots = %q, want the schema right after each version %q", snapshots, want)
+	}
+	if reflect.DeepEqual(snapshots[1], snapshots[2]) {
+		t.Errorf("snapshots of v1.0.0 and v1.1.0 are equal, %q", snapshots[1])
+	}
+}
+
+func TestApplyInOrderRecordError(t *testing.T) {
+	versions := []Version{
+		{Id: 1, Version: "v1.0.0", Up: NewScript("users")},
+		{Id: 2, Version: "v1.1.0", Up: NewScript("roles")},
+	}
+
+	target := &fakeTarget{}
+	failure := errors.New("metadata database gone")
+	_, err := applyInOrder(versions, target.run, func(v Version, result *VersionRunResult) error {
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("applyInOrder() error = %v, want %v", err, failure)
+	}
+	if want := []string{"users"}; !reflect.DeepEqual(target.tables, want) {
+		t.Errorf("tables = %q, want %q, no version may run after recording failed", target.tables, want)
+	}
+}
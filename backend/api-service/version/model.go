@@ -1,35 +1,485 @@
 package version
 
 import (
+	"backend/schemaDiff"
+	"crypto/sha256"
 	"database/sql/driver"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"time"
 )
 
 type CreateTableRequest struct {
-	ProjectID int                   `json:"projectId"`
-	TableName string                `json:"tableName"`
-	Columns   []CreateColumnRequest `json:"columns"`
+	ProjectID  int                   `json:"projectId"`
+	Version    string                `json:"version,omitempty"`
+	Bump       string                `json:"bump,omitempty"`
+	SchemaName string                `json:"schemaName,omitempty"`
+	TableName  string                `json:"tableName"`
+	Columns    []CreateColumnRequest `json:"columns"`
+	Preview    bool                  `json:"preview"`
 }
 
+// CreateColumnRequest describes a column with a logical type like
+// "string(255)", "int64" or "decimal(10,2)", see connectors.ParseLogicalType.
+// Default is a literal of that type or one of "now", "uuid" and "null".
 type CreateColumnRequest struct {
-	Name       string `json:"name"`
-	Type       string `json:"type"`
-	Nullable   bool   `json:"nullable"`
-	PrimaryKey bool   `json:"primaryKey"`
-	Default    string `json:"default,omitempty"`
+	Name          string `json:"name"`
+	Type          string `json:"type"`
+	Nullable      bool   `json:"nullable"`
+	PrimaryKey    bool   `json:"primaryKey"`
+	AutoIncrement bool   `json:"autoIncrement"`
+	Default       string `json:"default,omitempty"`
+}
+
+type AlterTableRequest struct {
+	ProjectID          int                   `json:"projectId"`
+	Version            string                `json:"version,omitempty"`
+	Bump               string                `json:"bump,omitempty"`
+	SchemaName         string                `json:"schemaName,omitempty"`
+	TableName          string                `json:"tableName"`
+	Operations         []AlterTableOperation `json:"operations"`
+	ConfirmDestructive bool                  `json:"confirmDestructive"`
+	Preview            bool                  `json:"preview"`
+}
+
+// AlterTableOperation is one change of an AlterTableRequest. Which fields are
+// used depends on the action. Type is a logical type; with set_default it is
+// optional and, when given, Default is checked and translated as for a new
+// column, otherwise Default is used as an SQL expression.
+type AlterTableOperation struct {
+	Action     string               `json:"action"`
+	Column     string               `json:"column,omitempty"`
+	NewName    string               `json:"newName,omitempty"`
+	Definition *CreateColumnRequest `json:"definition,omitempty"`
+	Type       string               `json:"type,omitempty"`
+	Nullable   *bool                `json:"nullable,omitempty"`
+	Default    string               `json:"default,omitempty"`
+}
+
+type DropTableRequest struct {
+	ProjectID          int    `json:"projectId"`
+	Version            string `json:"version,omitempty"`
+	Bump               string `json:"bump,omitempty"`
+	SchemaName         string `json:"schemaName,omitempty"`
+	TableName          string `json:"tableName"`
+	ConfirmDestructive bool   `json:"confirmDestructive"`
+	Preview            bool   `json:"preview"`
+}
+
+type IndexRequest struct {
+	ProjectID    int      `json:"projectId"`
+	Version      string   `json:"version,omitempty"`
+	Bump         string   `json:"bump,omitempty"`
+	SchemaName   string   `json:"schemaName,omitempty"`
+	TableName    string   `json:"tableName"`
+	Action       string   `json:"action"`
+	IndexName    string   `json:"indexName,omitempty"`
+	Columns      []string `json:"columns,omitempty"`
+	Unique       bool     `json:"unique"`
+	Where        string   `json:"where,omitempty"`
+	Concurrently bool     `json:"concurrently"`
+	Preview      bool     `json:"preview"`
+}
+
+type ConstraintRequest struct {
+	ProjectID         int      `json:"projectId"`
+	Version           string   `json:"version,omitempty"`
+	Bump              string   `json:"bump,omitempty"`
+	SchemaName        string   `json:"schemaName,omitempty"`
+	TableName         string   `json:"tableName"`
+	Action            string   `json:"action"`
+	ConstraintName    string   `json:"constraintName,omitempty"`
+	Type              string   `json:"type,omitempty"`
+	Columns           []string `json:"columns,omitempty"`
+	ReferencedSchema  string   `json:"referencedSchema,omitempty"`
+	ReferencedTable   string   `json:"referencedTable,omitempty"`
+	ReferencedColumns []string `json:"referencedColumns,omitempty"`
+	OnDelete          string   `json:"onDelete,omitempty"`
+	OnUpdate          string   `json:"onUpdate,omitempty"`
+	Check             string   `json:"check,omitempty"`
+	Preview           bool     `json:"preview"`
+}
+
+type TableChangeResponse struct {
+	Version     *Version      `json:"version,omitempty"`
+	Up          string        `json:"up"`
+	Down        string        `json:"down"`
+	Destructive []string      `json:"destructive"`
+	Warnings    []string      `json:"warnings"`
+	Lint        []PlanWarning `json:"lint"`
+}
+
+const (
+	StatePending    = "pending"
+	StateCompleted  = "completed"
+	StateFailed     = "failed"
+	StateRolledBack = "rolled_back"
+	// StateSquashed is a completed version that was replaced by a squash, it
+	// only remains as history and never runs again.
+	StateSquashed = "squashed"
+)
+
+// Kinds of versions. A repeatable version holds object definitions like views
+// and procedures, is named instead of labelled with a version, and runs again
+// after the versioned ones whenever its up script changes.
+const (
+	KindVersioned  = "versioned"
+	KindRepeatable = "repeatable"
+)
+
+type NewVersion struct {
+	ProjectID int
+	// Kind is versioned when empty.
+	Kind string
+	// Version is an explicit label, empty generates the next one. Repeatable
+	// versions need it as their name.
+	Version string
+	// Bump selects the semver part to increment when generating a label.
+	Bump string
+	Up   SQLScript
+	Down SQLScript
+	// AuthorID is the user that wrote the scripts, 0 stores no author.
+	AuthorID    int
+	Description string
+	// AcceptLintErrors stores the version even when its up script has lint
+	// errors, for versions that were written elsewhere. Apply still refuses
+	// to run it.
+	AcceptLintErrors bool
+}
+
+// RawVersionRequest creates a version from hand written scripts. Validate is
+// none, parse or dry_run; parse is the default. With kind repeatable, Version
+// is the name of the repeatable version.
+type RawVersionRequest struct {
+	ProjectID   int    `json:"projectId"`
+	Kind        string `json:"kind,omitempty"`
+	Version     string `json:"version,omitempty"`
+	Bump        string `json:"bump,omitempty"`
+	Description string `json:"description,omitempty"`
+	Up          string `json:"up"`
+	Down        string `json:"down"`
+	Validate    string `json:"validate,omitempty"`
+	Preview     bool   `json:"preview"`
+}
+
+type RawVersionResponse struct {
+	Version        *Version      `json:"version,omitempty"`
+	UpStatements   int           `json:"upStatements"`
+	DownStatements int           `json:"downStatements"`
+	Warnings       []PlanWarning `json:"warnings"`
+	DryRun         *DryRunResult `json:"dryRun,omitempty"`
+}
+
+// DryRunResult holds the output of scripts that were executed and rolled back.
+type DryRunResult struct {
+	Up   VersionRunResult  `json:"up"`
+	Down *VersionRunResult `json:"down,omitempty"`
+}
+
+type ImportRequest struct {
+	ProjectID int    `json:"projectId"`
+	Format    string `json:"format,omitempty"`
+	Baseline  bool   `json:"baseline"`
+}
+
+type ImportResponse struct {
+	Format    string            `json:"format"`
+	Imported  int               `json:"imported"`
+	Baselined int               `json:"baselined"`
+	Versions  []ImportedVersion `json:"versions"`
+	Warnings  []string          `json:"warnings"`
+}
+
+type ImportedVersion struct {
+	VersionRef
+	Source        string `json:"source"`
+	SourceVersion string `json:"sourceVersion"`
+	Baselined     bool   `json:"baselined"`
+}
+
+type ExportRequest struct {
+	ProjectID int
+	Format    string
+	Archive   string
+	// States limits the export to versions in these states, empty exports all.
+	States []string
+}
+
+type ExportResult struct {
+	FileName    string
+	ContentType string
+	Data        []byte
+}
+
+// ExportManifest is written as manifest.json into every export.
+type ExportManifest struct {
+	ProjectID     int               `json:"projectId"`
+	Format        string            `json:"format"`
+	VersionScheme string            `json:"versionScheme"`
+	ExportedAt    time.Time         `json:"exportedAt"`
+	Versions      []ExportedVersion `json:"versions"`
+}
+
+type ExportedVersion struct {
+	Version     string          `json:"version"`
+	Kind        string          `json:"kind"`
+	State       string          `json:"state"`
+	CreatedAt   string          `json:"createdAt"`
+	AppliedAt   *time.Time      `json:"appliedAt,omitempty"`
+	Description *string         `json:"description,omitempty"`
+	Up          ExportedScript  `json:"up"`
+	Down        *ExportedScript `json:"down,omitempty"`
+}
+
+type ExportedScript struct {
+	File     string `json:"file"`
+	Checksum string `json:"checksum"`
+}
+
+// BaselineRequest records the existing schema of a project's target as its
+// first version. Version overrides the default lowest label of the scheme.
+type BaselineRequest struct {
+	ProjectID          int    `json:"projectId"`
+	Version            string `json:"version,omitempty"`
+	LockTimeoutSeconds int    `json:"lockTimeoutSeconds,omitempty"`
+	Preview            bool   `json:"preview"`
+}
+
+type BaselineResponse struct {
+	Version    *VersionRef `json:"version,omitempty"`
+	Up         string      `json:"up"`
+	Tables     int         `json:"tables"`
+	Warnings   []string    `json:"warnings"`
+	SnapshotID *int        `json:"snapshotId,omitempty"`
+}
+
+// SquashRequest replaces the completed versions from FromVersionID to
+// ToVersionID with one version generated from their snapshots. A preview
+// returns the scripts without taking the lock or changing anything.
+type SquashRequest struct {
+	ProjectID          int    `json:"projectId"`
+	FromVersionID      int    `json:"fromVersionId"`
+	ToVersionID        int    `json:"toVersionId"`
+	Description        string `json:"description,omitempty"`
+	LockTimeoutSeconds int    `json:"lockTimeoutSeconds,omitempty"`
+	// ConfirmDropped squashes versions with data changes or objects other
+	// than tables, which the squashed version does not reproduce.
+	ConfirmDropped bool `json:"confirmDropped"`
+	Preview        bool `json:"preview"`
+}
+
+type SquashResponse struct {
+	Version    *Version     `json:"version,omitempty"`
+	Squashed   []VersionRef `json:"squashed"`
+	Up         string       `json:"up"`
+	Down       string       `json:"down"`
+	Warnings   []string     `json:"warnings"`
+	SnapshotID *int         `json:"snapshotId,omitempty"`
+}
+
+type CreateFromDiffRequest struct {
+	ProjectID          int                    `json:"projectId"`
+	Desired            schemaDiff.StateSource `json:"desired"`
+	SchemaMapping      map[string]string      `json:"schemaMapping,omitempty"`
+	ConfirmDestructive bool                   `json:"confirmDestructive"`
+	Preview            bool                   `json:"preview"`
+	Version            string                 `json:"version,omitempty"`
+	Bump               string                 `json:"bump,omitempty"`
+}
+
+type CreateFromDiffResponse struct {
+	Version *Version                   `json:"version,omitempty"`
+	Up      schemaDiff.MigrationScript `json:"up"`
+	Down    schemaDiff.MigrationScript `json:"down"`
+	Report  string                     `json:"report"`
+}
+
+// Transaction modes of an apply. Statements that cannot run in a transaction
+// on the target engine, such as DDL on MySQL, always run without one.
+const (
+	TransactionPerVersion = "version"
+	TransactionBatch      = "batch"
+	TransactionNone       = "none"
+)
+
+type ApplyRequest struct {
+	ProjectID          int    `json:"projectId"`
+	LockTimeoutSeconds int    `json:"lockTimeoutSeconds,omitempty"`
+	TransactionMode    string `json:"transactionMode,omitempty"`
+}
+
+type MigrationLock struct {
+	ProjectID  int       `db:"project_id" json:"projectId"`
+	HolderID   int       `db:"holder_id" json:"holderId"`
+	Operation  string    `db:"operation" json:"operation"`
+	Hostname   string    `db:"hostname" json:"hostname"`
+	BackendPID int       `db:"backend_pid" json:"backendPid"`
+	AcquiredAt time.Time `db:"acquired_at" json:"acquiredAt"`
+	Active     bool      `db:"active" json:"active"`
+}
+
+type RollbackRequest struct {
+	ProjectID          int    `json:"projectId"`
+	TargetVersionID    int    `json:"targetVersionId"`
+	Force              bool   `json:"force"`
+	LockTimeoutSeconds int    `json:"lockTimeoutSeconds,omitempty"`
+	TransactionMode    string `json:"transactionMode,omitempty"`
+}
+
+type RollbackResponse struct {
+	ProjectID       int                `json:"projectId"`
+	TargetVersionID int                `json:"targetVersionId"`
+	Success         bool               `json:"success"`
+	RolledBack      int                `json:"rolledBack"`
+	Versions        []VersionRunResult `json:"versions"`
+}
+
+type ValidationResponse struct {
+	ProjectID int             `json:"projectId"`
+	Valid     bool            `json:"valid"`
+	Issues    []ChecksumIssue `json:"issues"`
+}
+
+// ChecksumIssue is a script whose stored text no longer matches the checksum
+// recorded when it was created or applied.
+type ChecksumIssue struct {
+	VersionID int    `json:"versionId"`
+	Version   string `json:"version"`
+	State     string `json:"state"`
+	Script    string `json:"script"`
+	Expected  string `json:"expected"`
+	Actual    string `json:"actual"`
+	Reason    string `json:"reason"`
+}
+
+type VersionRef struct {
+	VersionID int    `json:"versionId"`
+	Version   string `json:"version"`
+}
+
+type PlanResponse struct {
+	ProjectID       int               `json:"projectId"`
+	Dialect         string            `json:"dialect"`
+	CurrentVersion  *VersionRef       `json:"currentVersion"`
+	TargetVersion   *VersionRef       `json:"targetVersion"`
+	TransactionMode string            `json:"transactionMode"`
+	HistoryTable    bool              `json:"historyTable"`
+	Reconciled      []ReconcileChange `json:"reconciled,omitempty"`
+	ChecksumIssues  []ChecksumIssue   `json:"checksumIssues"`
+	Blocked         bool              `json:"blocked"`
+	Lock            *MigrationLock    `json:"lock,omitempty"`
+	Warnings        []string          `json:"warnings,omitempty"`
+	Versions        []PlannedVersion  `json:"versions"`
+}
+
+type PlannedVersion struct {
+	VersionID     int                `json:"versionId"`
+	Version       string             `json:"version"`
+	Kind          string             `json:"kind"`
+	State         string             `json:"state"`
+	Checksum      string             `json:"checksum"`
+	Script        string             `json:"script"`
+	Transactional bool               `json:"transactional"`
+	Statements    []PlannedStatement `json:"statements"`
+	Warnings      []PlanWarning      `json:"warnings"`
+}
+
+type PlannedStatement struct {
+	Index         int    `json:"index"`
+	Statement     string `json:"statement"`
+	Transactional bool   `json:"transactional"`
+}
+
+// PlanWarning is a finding about one statement of a script. Lint findings
+// carry the rule that matched and its severity in the project.
+type PlanWarning struct {
+	Kind      string `db:"kind" json:"kind"`
+	Rule      string `db:"rule" json:"rule,omitempty"`
+	Severity  string `db:"severity" json:"severity,omitempty"`
+	Statement int    `db:"statement" json:"statement"`
+	Message   string `db:"message" json:"message"`
+}
+
+type LintRule struct {
+	Rule        string `json:"rule"`
+	Description string `json:"description"`
+	Severity    string `json:"severity"`
+	Default     string `json:"default"`
+}
+
+// UpdateLintRulesRequest maps rules to off, warning or error.
+type UpdateLintRulesRequest struct {
+	Rules map[string]string `json:"rules"`
+}
+
+type ApplyResponse struct {
+	ProjectID       int                `json:"projectId"`
+	Success         bool               `json:"success"`
+	TransactionMode string             `json:"transactionMode"`
+	Warnings        []string           `json:"warnings,omitempty"`
+	Applied         int                `json:"applied"`
+	Reconciled      []ReconcileChange  `json:"reconciled,omitempty"`
+	Versions        []VersionRunResult `json:"versions"`
+}
+
+// ReconcileChange is a version whose state was corrected to match the history
+// table of the target database.
+type ReconcileChange struct {
+	VersionID int    `json:"versionId"`
+	Version   string `json:"version"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+
+	AppliedChecksum string `json:"appliedChecksum,omitempty"`
+}
+
+type VersionRunResult struct {
+	VersionID  int               `json:"versionId"`
+	Version    string            `json:"version"`
+	Kind       string            `json:"kind,omitempty"`
+	State      string            `json:"state"`
+	DurationMs int64             `json:"durationMs"`
+	Output     []StatementOutput `json:"output"`
+	Error      string            `json:"error,omitempty"`
+	// FailedStatement is the 1-based index of the statement that failed.
+	FailedStatement  int      `json:"failedStatement,omitempty"`
+	Transactional    bool     `json:"transactional"`
+	PartiallyApplied bool     `json:"partiallyApplied,omitempty"`
+	Warnings         []string `json:"warnings,omitempty"`
+	SnapshotID       *int     `json:"snapshotId,omitempty"`
+}
+
+type StatementOutput struct {
+	Index        int    `json:"index"`
+	Statement    string `json:"statement"`
+	RowsAffected int64  `json:"rowsAffected"`
+	Error        string `json:"error,omitempty"`
 }
 
 type Version struct {
 	Id        int        `db:"id" json:"id"`
 	Version   string     `db:"version" json:"version"`
+	Kind      string     `db:"kind" json:"kind"`
 	Up        SQLScript  `db:"up" json:"up"`
 	Down      SQLScript  `db:"down" json:"down"`
 	State     string     `db:"state" json:"state"`
 	CreatedAt string     `db:"created_at" json:"createdAt"`
 	AppliedAt *time.Time `db:"applied_at" json:"appliedAt"`
 	ProjectId int        `db:"project_id" json:"projectId"`
+	SortKey   string     `db:"sort_key" json:"-"`
+
+	AuthorID    *int    `db:"author_id" json:"authorId,omitempty"`
+	Description *string `db:"description" json:"description,omitempty"`
+
+	UpChecksum      *string `db:"up_checksum" json:"upChecksum,omitempty"`
+	DownChecksum    *string `db:"down_checksum" json:"downChecksum,omitempty"`
+	AppliedChecksum *string `db:"applied_checksum" json:"appliedChecksum,omitempty"`
+
+	SquashedInto *int `db:"squashed_into" json:"squashedInto,omitempty"`
+
+	// Lint holds the findings of the up script where they were loaded.
+	Lint []PlanWarning `db:"-" json:"lint,omitempty"`
 }
 
 type SQLScript struct {
@@ -37,10 +487,60 @@ type SQLScript struct {
 	Script string `json:"script"`
 }
 
+// Checksum is the hex encoded SHA-256 of the script text.
+func (s SQLScript) Checksum() string {
+	sum := sha256.Sum256([]byte(s.Script))
+	return hex.EncodeToString(sum[:])
+}
+
 func (s SQLScript) Value() (driver.Value, error) {
 	return json.Marshal(s)
 }
 
+// VersionSummary is a version without its scripts, as returned by listings.
+type VersionSummary struct {
+	Id          int        `db:"id" json:"id"`
+	Version     string     `db:"version" json:"version"`
+	Kind        string     `db:"kind" json:"kind"`
+	State       string     `db:"state" json:"state"`
+	CreatedAt   string     `db:"created_at" json:"createdAt"`
+	AppliedAt   *time.Time `db:"applied_at" json:"appliedAt"`
+	ProjectId   int        `db:"project_id" json:"projectId"`
+	AuthorID    *int       `db:"author_id" json:"authorId,omitempty"`
+	Description *string    `db:"description" json:"description,omitempty"`
+	UpChecksum  *string    `db:"up_checksum" json:"upChecksum,omitempty"`
+
+	SquashedInto *int `db:"squashed_into" json:"squashedInto,omitempty"`
+}
+
+type VersionFilter struct {
+	ProjectID int
+	// States limits the listing to versions in these states, empty lists all.
+	States []string
+	// Kind limits the listing to one kind of version, empty lists both.
+	Kind   string
+	Limit  int
+	Offset int
+}
+
+type VersionListResponse struct {
+	Versions []VersionSummary `json:"versions"`
+	Total    int              `json:"total"`
+}
+
+type VersionDetail struct {
+	Version
+	Audit []VersionAudit `json:"audit"`
+}
+
+// UpdateVersionRequest edits a pending or repeatable version, nil fields stay
+// unchanged.
+type UpdateVersionRequest struct {
+	Up          *string `json:"up,omitempty"`
+	Down        *string `json:"down,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
 type VersionAudit struct {
 	ID        int       `db:"id" json:"id"`
 	VersionID int       `db:"version_id" json:"versionId"`
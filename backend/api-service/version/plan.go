@@ -0,0 +1,155 @@
+package version
+
+import (
+	"backend/connectors"
+	"context"
+	"fmt"
+	"sort"
+)
+
+const (
+	WarningDestructive      = "destructive"
+	WarningIndexBuild       = "index_build"
+	WarningTableRewrite     = "table_rewrite"
+	WarningNonTransactional = "non_transactional"
+)
+
+// Plan computes what Apply would do without changing anything. The target
+// database is only read to load its history table.
+func (m *Migrator) Plan() (*PlanResponse, error) {
+	ctx := context.Background()
+	db, target, err := m.openTarget(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	defer target.Close()
+
+	versions, err := m.repo.GetVersions(m.projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &PlanResponse{
+		ProjectID: m.projectID,
+		Dialect:   m.conn.Dialect(),
+		Versions:  []PlannedVersion{},
+	}
+
+	response.HistoryTable, err = m.conn.HasHistoryTable(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.HistoryTable {
+		history, err := m.conn.ReadHistory(ctx, target)
+		if err != nil {
+			return nil, err
+		}
+		response.Reconciled = reconcileChanges(versions, history)
+		versions = withChanges(versions, response.Reconciled)
+	} else {
+		response.Warnings = append(response.Warnings, fmt.Sprintf(
+			"%s does not exist yet, it will be created and seeded from the completed versions", connectors.HistoryTableName))
+	}
+
+	config, err := m.repo.GetLintConfig(m.projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	response.ChecksumIssues = ValidateChecksums(versions)
+	if len(response.ChecksumIssues) > 0 {
+		response.Blocked = true
+		response.Warnings = append(response.Warnings, "apply is blocked until the checksum issues are resolved")
+	}
+
+	response.Lock, err = m.repo.GetMigrationLock(m.projectID)
+	if err != nil {
+		return nil, err
+	}
+	if response.Lock != nil && response.Lock.Active {
+		response.Warnings = append(response.Warnings, fmt.Sprintf(
+			"project is currently locked by a running %s", response.Lock.Operation))
+	}
+
+	var toApply, repeatables []Version
+	for _, v := range versions {
+		if v.Kind == KindRepeatable {
+			if repeatableDue(v) {
+				repeatables = append(repeatables, v)
+			}
+			continue
+		}
+		switch v.State {
+		case StateCompleted:
+			response.CurrentVersion = &VersionRef{VersionID: v.Id, Version: v.Version}
+		case StatePending, StateFailed, StateRolledBack:
+			toApply = append(toApply, v)
+		}
+	}
+
+	mode, warnings := m.transactionMode(toApply)
+	response.TransactionMode = mode
+	response.Warnings = append(response.Warnings, warnings...)
+
+	for _, v := range toApply {
+		response.Versions = append(response.Versions, m.planVersion(v, mode, config))
+		response.TargetVersion = &VersionRef{VersionID: v.Id, Version: v.Version}
+	}
+	if response.TargetVersion == nil {
+		response.TargetVersion = response.CurrentVersion
+	}
+	for _, v := range repeatables {
+		response.Versions = append(response.Versions, m.planVersion(v, mode, config))
+	}
+
+	for _, planned := range response.Versions {
+		if lintError(planned.Version, planned.Warnings) != nil {
+			response.Blocked = true
+			response.Warnings = append(response.Warnings, "apply is blocked until the lint errors are resolved")
+			break
+		}
+	}
+
+	return response, nil
+}
+
+func (m *Migrator) planVersion(v Version, mode string, config LintConfig) PlannedVersion {
+	planned := PlannedVersion{
+		VersionID:     v.Id,
+		Version:       v.Version,
+		Kind:          v.Kind,
+		State:         v.State,
+		Checksum:      v.Up.Checksum(),
+		Script:        v.Up.Script,
+		Transactional: mode != TransactionNone && m.transactional(v.Up),
+		Statements:    []PlannedStatement{},
+		Warnings:      []PlanWarning{},
+	}
+
+	statements := m.conn.SplitStatements(v.Up.Script)
+	planned.Warnings = append(planned.Warnings, lintStatements(config, m.conn.Dialect(), statements)...)
+	planned.Warnings = append(planned.Warnings, lockWarnings(config, m.conn.Dialect(), statements)...)
+	for i, statement := range statements {
+		transactional := m.conn.SupportsTransaction(statement)
+		if !transactional {
+			planned.Warnings = append(planned.Warnings, PlanWarning{
+				Kind:      WarningNonTransactional,
+				Statement: i + 1,
+				Message:   "cannot be rolled back if a later statement fails",
+			})
+		}
+
+		planned.Statements = append(planned.Statements, PlannedStatement{
+			Index:         i + 1,
+			Statement:     statement,
+			Transactional: transactional,
+		})
+	}
+	sort.SliceStable(planned.Warnings, func(i, j int) bool {
+		return planned.Warnings[i].Statement < planned.Warnings[j].Statement
+	})
+
+	return planned
+}
@@ -0,0 +1,158 @@
+package version
+
+import (
+	"backend/connectors"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Validation modes of a raw SQL version.
+const (
+	ValidateNone   = "none"
+	ValidateParse  = "parse"
+	ValidateDryRun = "dry_run"
+)
+
+const operationDryRun = "dry_run"
+
+var ErrDryRunFailed = errors.New("dry run failed")
+
+// CreateRawVersion stores hand written up and down scripts as a pending
+// version. Depending on req.Validate the scripts are split into statements
+// first or executed in a transaction on the target that is rolled back.
+func (r *Repository) CreateRawVersion(m *Migrator, req RawVersionRequest) (*RawVersionResponse, error) {
+	up, down := NewScript(req.Up), NewScript(req.Down)
+
+	upStatements := m.conn.SplitStatements(up.Script)
+	downStatements := m.conn.SplitStatements(down.Script)
+
+	response := &RawVersionResponse{
+		UpStatements:   len(upStatements),
+		DownStatements: len(downStatements),
+		Warnings:       []PlanWarning{},
+	}
+	if req.Validate == ValidateNone {
+		return r.saveRawVersion(m, req, up, down, response)
+	}
+
+	if len(upStatements) == 0 {
+		return nil, fmt.Errorf("%w: up script has no statements", ErrInvalidOperation)
+	}
+	config, err := r.GetLintConfig(req.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	response.Warnings = append(response.Warnings, lintStatements(config, m.conn.Dialect(), upStatements)...)
+	if len(downStatements) == 0 && req.Kind != KindRepeatable {
+		response.Warnings = append(response.Warnings, PlanWarning{
+			Kind:    WarningDestructive,
+			Message: "no down script, the version cannot be rolled back without force",
+		})
+	}
+
+	if req.Validate == ValidateDryRun {
+		result, err := m.DryRun(up, down)
+		if err != nil {
+			return nil, err
+		}
+		response.DryRun = result
+	}
+
+	return r.saveRawVersion(m, req, up, down, response)
+}
+
+func (r *Repository) saveRawVersion(m *Migrator, req RawVersionRequest, up SQLScript, down SQLScript, response *RawVersionResponse) (*RawVersionResponse, error) {
+	if req.Preview {
+		return response, nil
+	}
+
+	version, err := r.InsertVersion(NewVersion{
+		ProjectID:   req.ProjectID,
+		Kind:        req.Kind,
+		Version:     req.Version,
+		Bump:        req.Bump,
+		Up:          up,
+		Down:        down,
+		AuthorID:    m.userID,
+		Description: strings.TrimSpace(req.Description),
+	})
+	if err != nil {
+		return nil, err
+	}
+	response.Version = &version
+
+	return response, nil
+}
+
+// DryRun executes the up and then the down script in one transaction on the
+// target and rolls it back, holding the project's migration lock. Scripts with
+// statements that control the transaction themselves or cannot run in one on
+// the engine are rejected, they would stay applied.
+func (m *Migrator) DryRun(up SQLScript, down SQLScript) (*DryRunResult, error) {
+	if err := m.checkDryRun("up", up); err != nil {
+		return nil, err
+	}
+	if err := m.checkDryRun("down", down); err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	db, target, err := m.openTarget(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	defer target.Close()
+
+	unlock, err := m.lock(ctx, target, operationDryRun)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	tx, err := target.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	v := Version{Version: "dry run"}
+	result := &DryRunResult{Up: m.run(ctx, tx, v, up)}
+	if result.Up.Error != "" {
+		return nil, fmt.Errorf("%w: up script %s", ErrDryRunFailed, result.Up.Error)
+	}
+
+	if strings.TrimSpace(down.Script) != "" {
+		downResult := m.run(ctx, tx, v, down)
+		if downResult.Error != "" {
+			return nil, fmt.Errorf("%w: down script %s", ErrDryRunFailed, downResult.Error)
+		}
+		result.Down = &downResult
+	}
+
+	return result, nil
+}
+
+// checkDryRun rejects a script whose changes would outlive the rolled back
+// transaction of a dry run.
+func (m *Migrator) checkDryRun(name string, script SQLScript) error {
+	for i, statement := range m.conn.SplitStatements(script.Script) {
+		if connectors.ControlsTransaction(m.conn.Dialect(), statement) {
+			return fmt.Errorf("%w: statement %d of the %s script controls the transaction itself and would end the dry run", ErrInvalidOperation, i+1, name)
+		}
+		if !m.conn.SupportsTransaction(statement) {
+			return fmt.Errorf("%w: statement %d of the %s script cannot run in a transaction on %s and would not be rolled back", ErrInvalidOperation, i+1, name, m.conn.Dialect())
+		}
+	}
+	return nil
+}
+
+func validValidateMode(mode string) bool {
+	switch mode {
+	case "", ValidateNone, ValidateParse, ValidateDryRun:
+		return true
+	}
+	return false
+}
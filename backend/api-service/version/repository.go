@@ -1,12 +1,17 @@
 package version
 
 import (
+	"backend/connectors"
 	"backend/core"
+	"database/sql"
+	"errors"
 	"fmt"
 	"math/rand/v2"
 	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type Repository struct {
@@ -17,91 +22,713 @@ func NewRepository(ctx *core.WebContext) *Repository {
 	return &Repository{db: ctx.GetDb()}
 }
 
-func GetCreateTableUpScript(tableName string, columns []CreateColumnRequest) (SQLScript, error) {
-	script := SQLScript{
-		ID:     rand.IntN(1000000),
-		Script: "",
+func NewRepositoryFromDb(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// CreateTable generates the scripts for a new table in the dialect of the
+// project's database and stores them as a pending version.
+func (r *Repository) CreateTable(conn connectors.DBConnector, req CreateTableRequest) (*TableChangeResponse, error) {
+	change, err := BuildCreateTable(conn, req)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = conn.GetTableDetail(req.ProjectID, req.SchemaName, req.TableName)
+	if err == nil {
+		return nil, fmt.Errorf("%w: table %s already exists", ErrInvalidOperation, req.TableName)
+	}
+	if !errors.Is(err, connectors.ErrTableNotFound) {
+		return nil, err
+	}
+
+	return r.saveTableChange(conn, change, NewVersion{
+		ProjectID: req.ProjectID,
+		Version:   req.Version,
+		Bump:      req.Bump,
+	}, false, req.Preview)
+}
+
+// AlterTable generates the scripts for a set of column and table changes from
+// the live definition of the table and stores them as a pending version.
+func (r *Repository) AlterTable(conn connectors.DBConnector, req AlterTableRequest) (*TableChangeResponse, error) {
+	table, err := conn.GetTableDetail(req.ProjectID, req.SchemaName, req.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	change, err := BuildAlterTable(conn, *table, req.Operations)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.saveTableChange(conn, change, NewVersion{
+		ProjectID: req.ProjectID,
+		Version:   req.Version,
+		Bump:      req.Bump,
+	}, req.ConfirmDestructive, req.Preview)
+}
+
+func (r *Repository) DropTable(conn connectors.DBConnector, req DropTableRequest) (*TableChangeResponse, error) {
+	table, err := conn.GetTableDetail(req.ProjectID, req.SchemaName, req.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.saveTableChange(conn, BuildDropTable(conn, *table), NewVersion{
+		ProjectID: req.ProjectID,
+		Version:   req.Version,
+		Bump:      req.Bump,
+	}, req.ConfirmDestructive, req.Preview)
+}
+
+func (r *Repository) ChangeIndex(conn connectors.DBConnector, req IndexRequest) (*TableChangeResponse, error) {
+	table, err := conn.GetTableDetail(req.ProjectID, req.SchemaName, req.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	change, err := BuildIndexChange(conn, *table, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.saveTableChange(conn, change, NewVersion{
+		ProjectID: req.ProjectID,
+		Version:   req.Version,
+		Bump:      req.Bump,
+	}, false, req.Preview)
+}
+
+func (r *Repository) ChangeConstraint(conn connectors.DBConnector, req ConstraintRequest) (*TableChangeResponse, error) {
+	table, err := conn.GetTableDetail(req.ProjectID, req.SchemaName, req.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	var referenced *connectors.TableStructureResponse
+	if req.Action == ActionCreate && req.Type == ConstraintForeignKey && req.ReferencedTable != "" {
+		schema := req.ReferencedSchema
+		if schema == "" {
+			schema = table.SchemaName
+		}
+		referenced, err = conn.GetTableDetail(req.ProjectID, schema, req.ReferencedTable)
+		if err != nil && !errors.Is(err, connectors.ErrTableNotFound) {
+			return nil, err
+		}
+	}
+
+	change, err := BuildConstraintChange(conn, *table, referenced, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.saveTableChange(conn, change, NewVersion{
+		ProjectID: req.ProjectID,
+		Version:   req.Version,
+		Bump:      req.Bump,
+	}, false, req.Preview)
+}
+
+func (r *Repository) saveTableChange(gen connectors.SchemaChangeGenerator, change *TableChange, nv NewVersion, confirmDestructive bool, preview bool) (*TableChangeResponse, error) {
+	response := &TableChangeResponse{
+		Up:          gen.JoinStatements(change.Up),
+		Down:        gen.JoinStatements(change.Down),
+		Destructive: append([]string{}, change.Destructive...),
+		Warnings:    append([]string{}, change.Warnings...),
+	}
+
+	config, err := r.GetLintConfig(nv.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	response.Lint = lintStatements(config, gen.Dialect(), change.Up)
+	if preview {
+		return response, nil
+	}
+
+	if len(change.Destructive) > 0 && !confirmDestructive {
+		return nil, fmt.Errorf("%w: %s", ErrDestructiveChange, strings.Join(change.Destructive, ", "))
+	}
+
+	nv.Up = NewScript(response.Up)
+	nv.Down = NewScript(response.Down)
+	version, err := r.InsertVersion(nv)
+	if err != nil {
+		return nil, err
+	}
+	response.Version = &version
+
+	return response, nil
+}
+
+// InsertVersion stores a new pending version. Every code path that creates a
+// version goes through here, except Squash, which inserts its completed
+// version in place of the squashed ones. Without an explicit label the next
+// version of the project's scheme is used; a concurrent insert of the same
+// label is retried. The up script is linted with the project's rules and the
+// findings are stored with the version.
+func (r *Repository) InsertVersion(nv NewVersion) (Version, error) {
+	var version Version
+
+	scheme, err := r.GetVersionScheme(nv.ProjectID)
+	if err != nil {
+		return version, err
+	}
+	if nv.Kind == "" {
+		nv.Kind = KindVersioned
 	}
 
-	if len(columns) == 0 {
-		return script, fmt.Errorf("no columns provided")
+	dialect, err := r.GetDialect(nv.ProjectID)
+	if err != nil {
+		return version, err
+	}
+	findings, err := r.Lint(nv.ProjectID, dialect, nv.Up.Script)
+	if err != nil {
+		return version, err
+	}
+	if err := lintError(nv.Version, findings); err != nil && !nv.AcceptLintErrors {
+		return version, err
 	}
 
-	var columnDefs []string
-	var primaryKeys []string
+	stmt, err := r.db.PrepareNamed(`
+		INSERT INTO versions (version, kind, sort_key, up, down, state, project_id, up_checksum, down_checksum, author_id, description)
+		VALUES (:version, :kind, :sortKey, :up, :down, :state, :projectId, :upChecksum, :downChecksum, NULLIF(:authorId, 0), NULLIF(:description, ''))
+		RETURNING ` + versionColumns)
+	if err != nil {
+		return version, err
+	}
+	defer stmt.Close()
 
-	for _, col := range columns {
-		def := fmt.Sprintf("%s %s", col.Name, col.Type)
+	for attempt := 0; attempt < 5; attempt++ {
+		label, sortKey, err := r.nextLabel(nv, scheme)
+		if err != nil {
+			return version, err
+		}
 
-		if col.Nullable {
-			def += " NOT NULL"
+		params := map[string]any{
+			"version":      label,
+			"kind":         nv.Kind,
+			"sortKey":      sortKey,
+			"up":           nv.Up,
+			"down":         nv.Down,
+			"state":        StatePending,
+			"projectId":    nv.ProjectID,
+			"upChecksum":   nv.Up.Checksum(),
+			"downChecksum": nv.Down.Checksum(),
+			"authorId":     nv.AuthorID,
+			"description":  nv.Description,
 		}
 
-		if col.Default != "" {
-			def += fmt.Sprintf(" DEFAULT %s", col.Default)
+		err = stmt.Get(&version, params)
+		if err == nil {
+			version.Lint = findings
+			return version, r.SaveLint(version.Id, findings)
+		}
+		if !isUniqueViolation(err) {
+			return version, err
+		}
+		if nv.Version != "" {
+			return version, fmt.Errorf("%w: %s", ErrVersionExists, nv.Version)
 		}
+	}
+
+	return version, ErrVersionExists
+}
 
-		columnDefs = append(columnDefs, def)
+// nextLabel validates an explicit label or generates the next one. Explicit
+// labels must sort after every completed version, so apply never runs a
+// version out of order. Repeatable versions only need a valid name.
+func (r *Repository) nextLabel(nv NewVersion, scheme string) (string, string, error) {
+	if nv.Kind == KindRepeatable {
+		sortKey, err := RepeatableSortKey(nv.Version)
+		return nv.Version, sortKey, err
+	}
 
-		if col.PrimaryKey {
-			primaryKeys = append(primaryKeys, col.Name)
+	if nv.Version == "" {
+		latest, _, err := r.GetLatestVersion(nv.ProjectID, false)
+		if err != nil {
+			return "", "", err
 		}
+		label, err := NextVersion(scheme, latest, nv.Bump, time.Now())
+		if err != nil {
+			return "", "", err
+		}
+		sortKey, err := SortKey(scheme, label)
+		return label, sortKey, err
+	}
+
+	sortKey, err := SortKey(scheme, nv.Version)
+	if err != nil {
+		return "", "", err
 	}
 
-	if len(primaryKeys) > 0 {
-		columnDefs = append(columnDefs, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(primaryKeys, ", ")))
+	applied, appliedKey, err := r.GetLatestVersion(nv.ProjectID, true)
+	if err != nil {
+		return "", "", err
+	}
+	if applied != "" && sortKey <= appliedKey {
+		return "", "", fmt.Errorf("%w: %s must come after the applied version %s", ErrInvalidVersion, nv.Version, applied)
 	}
 
-	upScript := fmt.Sprintf(
-		"CREATE TABLE %s (\n  %s\n);",
-		tableName,
-		strings.Join(columnDefs, ",\n  "),
-	)
+	return nv.Version, sortKey, nil
+}
 
-	script.Script = upScript
+func (r *Repository) GetVersionScheme(projectID int) (string, error) {
+	var scheme string
+	err := r.db.Get(&scheme, `SELECT version_scheme FROM projects WHERE id = $1`, projectID)
+	return scheme, err
+}
 
-	return script, nil
+// GetDialect returns the SQL dialect of a project's target database.
+func (r *Repository) GetDialect(projectID int) (string, error) {
+	var dialect string
+	err := r.db.Get(&dialect, `
+		SELECT ct.key
+		FROM connection_types ct
+		JOIN projects p ON p.connection_type = ct.id
+		WHERE p.id = $1`, projectID)
+	return dialect, err
 }
 
-func GetCreateTableDownScript(tableName string) (SQLScript, error) {
-	script := SQLScript{
+// GetLatestVersion returns the label and sort key of the last versioned
+// version of a project, or of its last completed one. Both are empty when
+// there is none.
+func (r *Repository) GetLatestVersion(projectID int, completedOnly bool) (string, string, error) {
+	var latest struct {
+		Version string `db:"version"`
+		SortKey string `db:"sort_key"`
+	}
+
+	err := r.db.Get(&latest, `
+		SELECT version, sort_key
+		FROM versions
+		WHERE project_id = $1 AND kind = 'versioned' AND (NOT $2 OR state = 'completed')
+		ORDER BY sort_key DESC, id DESC
+		LIMIT 1`, projectID, completedOnly)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", nil
+	}
+
+	return latest.Version, latest.SortKey, err
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+func NewScript(script string) SQLScript {
+	return SQLScript{
 		ID:     rand.IntN(1000000),
-		Script: "",
+		Script: script,
 	}
+}
 
-	downScript := fmt.Sprintf("DROP TABLE %s", tableName)
-	fmt.Println(tableName)
+const versionColumns = `id, version, kind, up, down, state, created_at, applied_at, project_id,
+	up_checksum, down_checksum, applied_checksum, sort_key, author_id, description, squashed_into`
 
-	script.Script = downScript
+// GetVersionsToApply returns the pending, failed and rolled back versioned
+// versions of a project in the order they have to run.
+func (r *Repository) GetVersionsToApply(projectID int) ([]Version, error) {
+	versions := []Version{}
 
-	return script, nil
+	stmt, err := r.db.PrepareNamed(`
+		SELECT ` + versionColumns + `
+		FROM versions
+		WHERE project_id = :projectId AND kind = 'versioned' AND state IN ('pending', 'failed', 'rolled_back')
+		ORDER BY sort_key, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	err = stmt.Select(&versions, map[string]any{"projectId": projectID})
+	return versions, err
 }
 
-func (r *Repository) CreateTable(ctr CreateTableRequest) error {
+// GetRepeatablesToApply returns the repeatable versions of a project that were
+// never applied or whose up script changed since, ordered by name.
+func (r *Repository) GetRepeatablesToApply(projectID int) ([]Version, error) {
+	repeatables := []Version{}
 
-	stmt, err := r.db.PrepareNamed("INSERT INTO versions (version, up, down, state, project_id) VALUES (:version, :up, :down, :state, :projectId)")
+	stmt, err := r.db.PrepareNamed(`
+		SELECT ` + versionColumns + `
+		FROM versions
+		WHERE project_id = :projectId AND kind = 'repeatable'
+		ORDER BY sort_key, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var versions []Version
+	if err := stmt.Select(&versions, map[string]any{"projectId": projectID}); err != nil {
+		return nil, err
+	}
+	for _, v := range versions {
+		if repeatableDue(v) {
+			repeatables = append(repeatables, v)
+		}
+	}
+	return repeatables, nil
+}
+
+// repeatableDue reports whether a repeatable version has to run, which is when
+// the up script that was last applied is not the current one.
+func repeatableDue(v Version) bool {
+	return v.State != StateCompleted || v.AppliedChecksum == nil || *v.AppliedChecksum != v.Up.Checksum()
+}
+
+func (r *Repository) SetVersionState(versionID int, state string) error {
+	stmt, err := r.db.PrepareNamed(`
+		UPDATE versions
+		SET state = :state,
+		    applied_at = CASE WHEN :state = 'rolled_back' THEN NULL ELSE NOW() END,
+		    applied_checksum = CASE WHEN :state = 'completed' THEN applied_checksum END
+		WHERE id = :id`)
 	if err != nil {
 		return err
 	}
+	defer stmt.Close()
 
-	upScript, err := GetCreateTableUpScript(ctr.TableName, ctr.Columns)
+	_, err = stmt.Exec(map[string]any{"id": versionID, "state": state})
+	return err
+}
+
+func (r *Repository) CreateAudit(versionID int, userID int, notes string) error {
+	stmt, err := r.db.PrepareNamed(`
+		INSERT INTO version_audit (version_id, applied_at, applied_by, notes)
+		VALUES (:versionId, NOW(), :userId, :notes)`)
 	if err != nil {
 		return err
 	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(map[string]any{"versionId": versionID, "userId": userID, "notes": notes})
+	return err
+}
+
+func (r *Repository) GetVersion(projectID int, versionID int) (*Version, error) {
+	stmt, err := r.db.PrepareNamed(`
+		SELECT ` + versionColumns + `
+		FROM versions
+		WHERE project_id = :projectId AND id = :id`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var version Version
+	if err := stmt.Get(&version, map[string]any{"projectId": projectID, "id": versionID}); err != nil {
+		return nil, err
+	}
+
+	return &version, nil
+}
+
+// GetVersionsToRollback returns the completed versioned versions after the
+// target in reverse order, which is the order their down scripts have to run.
+// Repeatable versions are not rolled back.
+func (r *Repository) GetVersionsToRollback(projectID int, targetVersionID int) ([]Version, error) {
+	versions := []Version{}
+
+	stmt, err := r.db.PrepareNamed(`
+		SELECT ` + versionColumns + `
+		FROM versions
+		WHERE project_id = :projectId AND kind = 'versioned' AND state = 'completed'
+		  AND (:targetId = 0 OR sort_key > (SELECT sort_key FROM versions WHERE id = :targetId))
+		ORDER BY sort_key DESC, id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	err = stmt.Select(&versions, map[string]any{"projectId": projectID, "targetId": targetVersionID})
+	return versions, err
+}
+
+// GetVersions returns the versions of a project in order, without the ones
+// that were squashed.
+func (r *Repository) GetVersions(projectID int) ([]Version, error) {
+	versions := []Version{}
+
+	stmt, err := r.db.PrepareNamed(`
+		SELECT ` + versionColumns + `
+		FROM versions
+		WHERE project_id = :projectId AND state <> 'squashed'
+		ORDER BY sort_key, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	err = stmt.Select(&versions, map[string]any{"projectId": projectID})
+	return versions, err
+}
 
-	downScript, err := GetCreateTableDownScript(ctr.TableName)
+// SetAppliedChecksum records the checksum of the up script that actually ran.
+func (r *Repository) SetAppliedChecksum(versionID int, checksum string) error {
+	stmt, err := r.db.PrepareNamed(`
+		UPDATE versions
+		SET applied_checksum = :checksum
+		WHERE id = :id`)
 	if err != nil {
 		return err
 	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(map[string]any{"id": versionID, "checksum": checksum})
+	return err
+}
+
+// ListVersions returns one page of a project's versions, newest first, and
+// the number of versions matching the filter.
+func (r *Repository) ListVersions(filter VersionFilter) (*VersionListResponse, error) {
+	response := &VersionListResponse{Versions: []VersionSummary{}}
+	where := `
+		FROM versions
+		WHERE project_id = :projectId
+		  AND (cardinality(CAST(:states AS text[])) = 0 OR state = ANY(CAST(:states AS text[])))
+		  AND (:kind = '' OR kind = :kind)`
 
 	params := map[string]any{
-		"version":   "v1.0.0",
-		"up":        upScript,
-		"down":      downScript,
-		"state":     "pending",
-		"projectId": ctr.ProjectID,
+		"projectId": filter.ProjectID,
+		"states":    pq.Array(filter.States),
+		"kind":      filter.Kind,
+		"limit":     filter.Limit,
+		"offset":    filter.Offset,
 	}
 
-	_, err = stmt.Exec(params)
-	return err
+	countStmt, err := r.db.PrepareNamed(`SELECT COUNT(*)` + where)
+	if err != nil {
+		return nil, err
+	}
+	defer countStmt.Close()
+
+	if err := countStmt.Get(&response.Total, params); err != nil {
+		return nil, err
+	}
+
+	stmt, err := r.db.PrepareNamed(`
+		SELECT id, version, kind, state, created_at, applied_at, project_id, author_id, description, up_checksum, squashed_into` + where + `
+		ORDER BY sort_key DESC, id DESC
+		LIMIT :limit OFFSET :offset`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	err = stmt.Select(&response.Versions, params)
+	return response, err
+}
+
+// GetVersionDetail returns a version with its scripts and audit trail.
+func (r *Repository) GetVersionDetail(projectID int, versionID int) (*VersionDetail, error) {
+	version, err := r.GetVersion(projectID, versionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrVersionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &VersionDetail{Version: *version, Audit: []VersionAudit{}}
+	detail.Lint, err = r.GetVersionLint(versionID)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, err := r.db.PrepareNamed(`
+		SELECT id, version_id, applied_at, applied_by, notes
+		FROM version_audit
+		WHERE version_id = :versionId
+		ORDER BY applied_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	err = stmt.Select(&detail.Audit, map[string]any{"versionId": versionID})
+	return detail, err
+}
+
+// UpdateVersion replaces the scripts or description of a version and
+// recomputes the checksums. Versioned versions can only be edited while they
+// are pending, repeatable versions in any state: a changed up script makes the
+// next apply run them again. The state is checked in the same statement, so a
+// version that is being applied concurrently is not changed.
+func (r *Repository) UpdateVersion(projectID int, versionID int, userID int, req UpdateVersionRequest) (*Version, error) {
+	current, err := r.GetVersion(projectID, versionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrVersionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if current.State != StatePending && current.Kind != KindRepeatable {
+		return nil, fmt.Errorf("%w: %s is %s", ErrVersionNotPending, current.Version, current.State)
+	}
+
+	up, down := current.Up, current.Down
+	var changed []string
+	if req.Up != nil && *req.Up != up.Script {
+		if strings.TrimSpace(*req.Up) == "" {
+			return nil, fmt.Errorf("%w: up script cannot be empty", ErrInvalidOperation)
+		}
+		up.Script = *req.Up
+		changed = append(changed, "up")
+	}
+	if req.Down != nil && *req.Down != down.Script {
+		down.Script = *req.Down
+		changed = append(changed, "down")
+	}
+	description := current.Description
+	if req.Description != nil {
+		trimmed := strings.TrimSpace(*req.Description)
+		description = &trimmed
+		changed = append(changed, "description")
+	}
+	if len(changed) == 0 {
+		return current, nil
+	}
+
+	var findings []PlanWarning
+	if up.Script != current.Up.Script {
+		dialect, err := r.GetDialect(projectID)
+		if err != nil {
+			return nil, err
+		}
+		if findings, err = r.Lint(projectID, dialect, up.Script); err != nil {
+			return nil, err
+		}
+		if err := lintError(current.Version, findings); err != nil {
+			return nil, err
+		}
+	}
+
+	stmt, err := r.db.PrepareNamed(`
+		UPDATE versions
+		SET up = :up, down = :down, up_checksum = :upChecksum, down_checksum = :downChecksum,
+		    description = NULLIF(:description, '')
+		WHERE project_id = :projectId AND id = :id AND state = :state
+		RETURNING ` + versionColumns)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	params := map[string]any{
+		"up":           up,
+		"down":         down,
+		"upChecksum":   up.Checksum(),
+		"downChecksum": down.Checksum(),
+		"description":  "",
+		"projectId":    projectID,
+		"id":           versionID,
+		"state":        current.State,
+	}
+	if description != nil {
+		params["description"] = *description
+	}
+
+	var version Version
+	err = stmt.Get(&version, params)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s changed state while it was edited", ErrVersionNotPending, current.Version)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.CreateAudit(versionID, userID, "edited "+strings.Join(changed, ", ")); err != nil {
+		return nil, err
+	}
+	if findings != nil {
+		if err := r.SaveLint(versionID, findings); err != nil {
+			return nil, err
+		}
+		version.Lint = findings
+	}
+
+	return &version, nil
+}
+
+// DeleteVersion removes a pending version together with its audit trail.
+func (r *Repository) DeleteVersion(projectID int, versionID int) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var state string
+	err = tx.Get(&state, `
+		SELECT state FROM versions
+		WHERE project_id = $1 AND id = $2
+		FOR UPDATE`, projectID, versionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrVersionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if state != StatePending {
+		return fmt.Errorf("%w: version is %s", ErrVersionNotPending, state)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM version_audit WHERE version_id = $1`, versionID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM versions WHERE id = $1`, versionID); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return fmt.Errorf("%w: version is referenced by %s", ErrVersionNotPending, versionReference(pqErr.Constraint))
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// versionReference names what holds on to a version, by the default names of
+// the foreign keys in setup_db.sql.
+func versionReference(constraint string) string {
+	switch constraint {
+	case "releases_current_version_fkey":
+		return "a release"
+	case "schema_snapshots_version_id_fkey":
+		return "a schema snapshot"
+	case "drift_checks_version_id_fkey":
+		return "a drift check"
+	case "versions_squashed_into_fkey":
+		return "versions squashed into it"
+	}
+	return "another record"
+}
+
+// removeVersions deletes versions that were just inserted, with their audit
+// trail, in one transaction. It undoes a partly failed import.
+func (r *Repository) removeVersions(projectID int, versions []Version) error {
+	if len(versions) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(versions))
+	for i, v := range versions {
+		ids[i] = int64(v.Id)
+	}
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM version_audit WHERE version_id = ANY($1)`, pq.Array(ids)); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM versions WHERE project_id = $1 AND id = ANY($2)`, projectID, pq.Array(ids)); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
@@ -0,0 +1,385 @@
+package version
+
+import (
+	"backend/connectors"
+	"backend/schemaDiff"
+	"backend/snapshot"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+const operationSquash = "squash"
+
+var (
+	ErrInvalidRange    = errors.New("invalid version range")
+	ErrSnapshotMissing = errors.New("schema snapshot missing")
+	ErrNotSquashable   = errors.New("versions cannot be squashed")
+)
+
+// unsquashable matches the leading words of statements whose effect is not
+// part of a schema snapshot, so the squashed script does not reproduce them.
+var unsquashable = regexp.MustCompile(`^(INSERT|UPDATE|DELETE|MERGE|REPLACE|COPY|WITH|GRANT|REVOKE|` +
+	`(CREATE|ALTER|DROP)( OR (REPLACE|ALTER))? (VIEW|MATERIALIZED VIEW|FUNCTION|PROCEDURE|PROC|TRIGGER|SEQUENCE|TYPE|DOMAIN|EXTENSION|RULE|EVENT))\b`)
+
+// squashPlan is a squash worked out from the metadata before anything is
+// written.
+type squashPlan struct {
+	response *SquashResponse
+	squashed []Version
+	// structure is the whole schema after the range, stored as the snapshot
+	// of the new version.
+	structure *connectors.DatabaseStructureResponse
+	// lost lists the versions with statements the scripts do not reproduce.
+	lost []string
+}
+
+// Squash replaces a contiguous range of completed versions with one version
+// whose scripts are generated from the snapshots before and after the range,
+// limited to the tables its scripts touch. The new version takes the label and
+// position of the last version of the range and is marked completed without
+// running, the originals stay in the project in the squashed state and point
+// to it. Data changes and objects that snapshots do not hold would be lost, so
+// such ranges are refused unless req.ConfirmDropped is set. A preview only
+// reads the metadata.
+func (m *Migrator) Squash(req SquashRequest) (*SquashResponse, error) {
+	if req.Preview {
+		plan, err := m.planSquash(req)
+		if err != nil {
+			return nil, err
+		}
+		return plan.response, nil
+	}
+
+	ctx := context.Background()
+	db, target, err := m.openTarget(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	defer target.Close()
+
+	unlock, err := m.lock(ctx, target, operationSquash)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if _, err := m.reconcile(ctx, target); err != nil {
+		return nil, fmt.Errorf("failed to reconcile with %s: %w", connectors.HistoryTableName, err)
+	}
+
+	plan, err := m.planSquash(req)
+	if err != nil {
+		return nil, err
+	}
+	if len(plan.lost) > 0 && !req.ConfirmDropped {
+		return nil, fmt.Errorf("%w: %s change data or objects other than tables, which the squashed version would not reproduce; confirm to drop them",
+			ErrNotSquashable, strings.Join(plan.lost, ", "))
+	}
+
+	response := plan.response
+	squashed := plan.squashed
+	last := squashed[len(squashed)-1]
+
+	description := strings.TrimSpace(req.Description)
+	if description == "" {
+		description = fmt.Sprintf("squash of %s to %s (%d versions)", squashed[0].Version, last.Version, len(squashed))
+	}
+
+	version, err := m.repo.insertSquash(last, squashed, NewVersion{
+		ProjectID:   m.projectID,
+		Up:          NewScript(response.Up),
+		Down:        NewScript(response.Down),
+		AuthorID:    m.userID,
+		Description: description,
+	})
+	if err != nil {
+		return nil, err
+	}
+	response.Version = version
+
+	findings, err := m.repo.Lint(m.projectID, m.conn.Dialect(), version.Up.Script)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.repo.SaveLint(version.Id, findings); err != nil {
+		return nil, err
+	}
+	version.Lint = findings
+
+	for _, v := range squashed {
+		if err := m.repo.CreateAudit(v.Id, m.userID, fmt.Sprintf("squashed into %s (%d)", version.Version, version.Id)); err != nil {
+			return nil, err
+		}
+	}
+	if err := m.markApplied(ctx, target, *version, fmt.Sprintf("squash of %d versions, not executed", len(squashed))); err != nil {
+		return nil, err
+	}
+
+	versionRef, userRef := version.Id, m.userID
+	captured, err := m.snapshots.Store(plan.structure, snapshot.CaptureRequest{
+		ProjectID: m.projectID,
+		VersionID: &versionRef,
+		UserID:    &userRef,
+		Source:    snapshot.SourceVersion,
+	})
+	if err != nil {
+		response.Warnings = append(response.Warnings, "failed to store schema snapshot: "+err.Error())
+	} else {
+		response.SnapshotID = &captured.Snapshot.ID
+	}
+
+	return response, nil
+}
+
+// planSquash validates the range and generates the scripts of the squashed
+// version. Only tables named in the up scripts of the range are compared, so
+// tables created outside of versions, before the first one or between
+// snapshots, do not become part of it.
+func (m *Migrator) planSquash(req SquashRequest) (*squashPlan, error) {
+	squashed, previous, err := m.squashRange(req.FromVersionID, req.ToVersionID)
+	if err != nil {
+		return nil, err
+	}
+	last := squashed[len(squashed)-1]
+
+	before := baselineStart(m.conn.Dialect())
+	if previous != nil {
+		if before, err = m.versionStructure(*previous); err != nil {
+			return nil, err
+		}
+	}
+	after, err := m.versionStructure(last)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &squashPlan{
+		squashed:  squashed,
+		structure: after,
+		response:  &SquashResponse{Squashed: []VersionRef{}},
+	}
+
+	var scripts []string
+	for _, v := range squashed {
+		plan.response.Squashed = append(plan.response.Squashed, VersionRef{VersionID: v.Id, Version: v.Version})
+		scripts = append(scripts, v.Up.Script)
+		for _, statement := range connectors.InspectStatements(m.conn.Dialect(), v.Up.Script) {
+			if unsquashable.MatchString(connectors.LeadingWords(statement, 5)) {
+				plan.lost = append(plan.lost, v.Version)
+				break
+			}
+		}
+	}
+	script := strings.Join(scripts, "\n")
+
+	touched := map[string]bool{}
+	for _, structure := range []*connectors.DatabaseStructureResponse{before, after} {
+		for _, schema := range structure.Schemas {
+			for _, table := range schema.Tables {
+				name := strings.ToLower(table.TableName)
+				if _, seen := touched[name]; !seen {
+					touched[name] = namesTable(script, table.TableName)
+				}
+			}
+		}
+	}
+	before, after = touchedTables(before, touched), touchedTables(after, touched)
+
+	if previous == nil {
+		// Without a version before the range every table is new to it, a
+		// table the range only changes existed before the first version.
+		for _, schema := range after.Schemas {
+			for _, table := range schema.Tables {
+				if !createsTable(script, table.TableName) {
+					return nil, fmt.Errorf("%w: %s is changed by the range but existed before its first version, baseline the project first",
+						ErrNotSquashable, table.TableName)
+				}
+			}
+		}
+	}
+
+	up := schemaDiff.GenerateMigration(m.conn, before, after)
+	down := schemaDiff.GenerateMigration(m.conn, after, before)
+	if len(up.Statements) == 0 {
+		return nil, fmt.Errorf("%w: the versions leave the schema unchanged, nothing to squash", ErrInvalidRange)
+	}
+
+	plan.response.Up = up.Script
+	plan.response.Down = down.Script
+	plan.response.Warnings = append([]string{}, up.Warnings...)
+	if len(plan.lost) > 0 {
+		plan.response.Warnings = append(plan.response.Warnings, fmt.Sprintf(
+			"data changes and objects other than tables are not part of snapshots and are not reproduced, they are used in %s",
+			strings.Join(plan.lost, ", ")))
+	}
+	return plan, nil
+}
+
+// namesTable reports whether a script mentions a table name as an identifier.
+func namesTable(script string, table string) bool {
+	return regexp.MustCompile(`(?i)(^|[^A-Za-z0-9_$])` + regexp.QuoteMeta(table) + `($|[^A-Za-z0-9_$])`).MatchString(script)
+}
+
+// createsTable reports whether a script creates a table or renames one to it.
+func createsTable(script string, table string) bool {
+	return regexp.MustCompile(`(?is)\b(CREATE\s+TABLE|RENAME|sp_rename)\b[^;]*?(^|[^A-Za-z0-9_$])` +
+		regexp.QuoteMeta(table) + `($|[^A-Za-z0-9_$])`).MatchString(script)
+}
+
+// touchedTables keeps the tables of a structure whose lower-cased name is
+// touched. Schemas that only held other tables are left out as well.
+func touchedTables(structure *connectors.DatabaseStructureResponse, touched map[string]bool) *connectors.DatabaseStructureResponse {
+	result := &connectors.DatabaseStructureResponse{Schemas: []connectors.SchemaStructureResponse{}}
+	for _, schema := range structure.Schemas {
+		tables := []connectors.TableStructureResponse{}
+		for _, table := range schema.Tables {
+			if touched[strings.ToLower(table.TableName)] {
+				tables = append(tables, table)
+			}
+		}
+		if len(tables) == 0 && len(schema.Tables) > 0 {
+			continue
+		}
+		result.Schemas = append(result.Schemas, connectors.SchemaStructureResponse{SchemaName: schema.SchemaName, Tables: tables})
+	}
+	return result
+}
+
+// squashRange returns the versions from the first to the last id in order and
+// the last completed version before them, nil when the range starts at the
+// first version. Every versioned version in between has to be completed.
+func (m *Migrator) squashRange(fromID int, toID int) ([]Version, *Version, error) {
+	versions, err := m.repo.GetVersions(m.projectID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	from, to := -1, -1
+	for i, v := range versions {
+		if v.Id == fromID {
+			from = i
+		}
+		if v.Id == toID {
+			to = i
+		}
+	}
+	if from < 0 || to < 0 {
+		return nil, nil, fmt.Errorf("%w: both versions must exist and not be squashed already", ErrInvalidRange)
+	}
+
+	var squashed []Version
+	var previous *Version
+	for i, v := range versions {
+		if v.Kind == KindRepeatable {
+			continue
+		}
+		if i < from {
+			if v.State == StateCompleted {
+				previous = &versions[i]
+			}
+			continue
+		}
+		if i > to {
+			break
+		}
+		if v.State != StateCompleted {
+			return nil, nil, fmt.Errorf("%w: %s is %s, only completed versions can be squashed", ErrInvalidRange, v.Version, v.State)
+		}
+		squashed = append(squashed, v)
+	}
+
+	if len(squashed) < 2 {
+		return nil, nil, fmt.Errorf("%w: the range needs at least two versions in order", ErrInvalidRange)
+	}
+	if squashed[0].Id != fromID || squashed[len(squashed)-1].Id != toID {
+		return nil, nil, fmt.Errorf("%w: the range must start and end with a versioned version", ErrInvalidRange)
+	}
+	return squashed, previous, nil
+}
+
+// versionStructure loads the schema after a version from its snapshot.
+func (m *Migrator) versionStructure(v Version) (*connectors.DatabaseStructureResponse, error) {
+	detail, err := m.snapshots.GetSnapshotForVersion(m.projectID, v.Id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: no snapshot was recorded for %s", ErrSnapshotMissing, v.Version)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	structure := connectors.DatabaseStructureResponse(detail.Structure)
+	return connectors.WithoutHistoryTable(&structure), nil
+}
+
+// insertSquash stores the squashed version as completed in place of last and
+// moves the originals to the squashed state in one transaction. The originals
+// leave the unique label and sort key indexes, which only cover versions that
+// are not squashed.
+func (r *Repository) insertSquash(last Version, squashed []Version, nv NewVersion) (*Version, error) {
+	ids := make([]int64, len(squashed))
+	for i, v := range squashed {
+		ids[i] = int64(v.Id)
+	}
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		UPDATE versions
+		SET state = 'squashed'
+		WHERE project_id = $1 AND id = ANY($2) AND state = 'completed'`, nv.ProjectID, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	if affected, err := res.RowsAffected(); err != nil || affected != int64(len(ids)) {
+		return nil, fmt.Errorf("%w: versions of the range changed state while squashing", ErrInvalidRange)
+	}
+
+	stmt, err := tx.PrepareNamed(`
+		INSERT INTO versions (version, kind, sort_key, up, down, state, applied_at, project_id,
+		                      up_checksum, down_checksum, applied_checksum, author_id, description)
+		VALUES (:version, :kind, :sortKey, :up, :down, :state, NOW(), :projectId,
+		        :upChecksum, :downChecksum, :upChecksum, NULLIF(:authorId, 0), NULLIF(:description, ''))
+		RETURNING ` + versionColumns)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var version Version
+	err = stmt.Get(&version, map[string]any{
+		"version":      last.Version,
+		"kind":         KindVersioned,
+		"sortKey":      last.SortKey,
+		"up":           nv.Up,
+		"down":         nv.Down,
+		"state":        StateCompleted,
+		"projectId":    nv.ProjectID,
+		"upChecksum":   nv.Up.Checksum(),
+		"downChecksum": nv.Down.Checksum(),
+		"authorId":     nv.AuthorID,
+		"description":  nv.Description,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(`UPDATE versions SET squashed_into = $1 WHERE id = ANY($2)`, version.Id, pq.Array(ids)); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
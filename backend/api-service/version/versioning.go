@@ -0,0 +1,160 @@
+package version
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version schemes a project can use for its version labels.
+const (
+	SchemeSemver    = "semver"
+	SchemeTimestamp = "timestamp"
+)
+
+// Parts of a semantic version that Bump can increment.
+const (
+	BumpMajor = "major"
+	BumpMinor = "minor"
+	BumpPatch = "patch"
+)
+
+const timestampLayout = "20060102150405"
+
+var (
+	ErrInvalidVersion = errors.New("invalid version")
+	ErrVersionExists  = errors.New("version already exists in this project")
+
+	semverPattern     = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z.-]+))?$`)
+	timestampPattern  = regexp.MustCompile(`^\d{14}$`)
+	repeatablePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.-]{0,49}$`)
+)
+
+// SortKey turns a version label into a key whose lexical order is the order
+// versions are applied in.
+func SortKey(scheme string, label string) (string, error) {
+	switch scheme {
+	case SchemeTimestamp:
+		if !timestampPattern.MatchString(label) {
+			return "", fmt.Errorf("%w: %q is not a timestamp like %s", ErrInvalidVersion, label, timestampLayout)
+		}
+		if _, err := time.Parse(timestampLayout, label); err != nil {
+			return "", fmt.Errorf("%w: %q: %v", ErrInvalidVersion, label, err)
+		}
+		return label, nil
+
+	case SchemeSemver:
+		match := semverPattern.FindStringSubmatch(label)
+		if match == nil {
+			return "", fmt.Errorf("%w: %q is not a semantic version like v1.2.3", ErrInvalidVersion, label)
+		}
+
+		parts := make([]string, 3)
+		for i := range parts {
+			n, err := strconv.Atoi(match[i+1])
+			if err != nil || n > 999999 {
+				return "", fmt.Errorf("%w: %q has a component out of range", ErrInvalidVersion, label)
+			}
+			parts[i] = fmt.Sprintf("%06d", n)
+		}
+
+		// A pre-release sorts before its release, '-' sorts before '~'.
+		suffix := "~"
+		if match[4] != "" {
+			prerelease, err := prereleaseKey(match[4])
+			if err != nil {
+				return "", fmt.Errorf("%w: %q %v", ErrInvalidVersion, label, err)
+			}
+			suffix = "-" + prerelease
+		}
+		return strings.Join(parts, ".") + suffix, nil
+	}
+
+	return "", fmt.Errorf("unknown version scheme %q", scheme)
+}
+
+// prereleaseKey orders pre-release identifiers like semantic versioning does:
+// numeric ones by value and before alphanumeric ones, which compare lexically,
+// and a shorter list before a longer one it starts. Numeric identifiers are
+// prefixed with their length and '!' separates identifiers, it sorts before
+// every character an identifier may contain.
+func prereleaseKey(prerelease string) (string, error) {
+	identifiers := strings.Split(prerelease, ".")
+	for i, identifier := range identifiers {
+		if identifier == "" {
+			return "", errors.New("has an empty pre-release identifier")
+		}
+		if strings.Trim(identifier, "0123456789") != "" {
+			identifiers[i] = "1" + identifier
+			continue
+		}
+		digits := strings.TrimLeft(identifier, "0")
+		if digits == "" {
+			digits = "0"
+		}
+		if len(digits) > 9 {
+			return "", errors.New("has a numeric pre-release identifier out of range")
+		}
+		identifiers[i] = "0" + strconv.Itoa(len(digits)) + digits
+	}
+	return strings.Join(identifiers, "!"), nil
+}
+
+// RepeatableSortKey returns the sort key of a repeatable version. Keys of both
+// schemes start with a digit and '~' sorts after digits, so repeatable
+// versions come after every versioned one, ordered by name.
+func RepeatableSortKey(name string) (string, error) {
+	if !repeatablePattern.MatchString(name) {
+		return "", fmt.Errorf("%w: %q is not a repeatable version name, use letters, digits, '_', '.' and '-'", ErrInvalidVersion, name)
+	}
+	return "~" + name, nil
+}
+
+// NextVersion returns the label that follows latest. An empty latest starts
+// the sequence.
+func NextVersion(scheme string, latest string, bump string, now time.Time) (string, error) {
+	switch scheme {
+	case SchemeTimestamp:
+		next := now.UTC()
+		if latest != "" {
+			previous, err := time.Parse(timestampLayout, latest)
+			if err == nil && !next.After(previous) {
+				next = previous.Add(time.Second)
+			}
+		}
+		return next.Format(timestampLayout), nil
+
+	case SchemeSemver:
+		if latest == "" {
+			return "v1.0.0", nil
+		}
+
+		match := semverPattern.FindStringSubmatch(latest)
+		if match == nil {
+			return "", fmt.Errorf("%w: latest version %q is not a semantic version", ErrInvalidVersion, latest)
+		}
+		major, _ := strconv.Atoi(match[1])
+		minor, _ := strconv.Atoi(match[2])
+		patch, _ := strconv.Atoi(match[3])
+
+		switch bump {
+		case BumpMajor:
+			major, minor, patch = major+1, 0, 0
+		case BumpMinor:
+			minor, patch = minor+1, 0
+		case BumpPatch, "":
+			// A pre-release is followed by its release.
+			if match[4] == "" {
+				patch++
+			}
+		default:
+			return "", fmt.Errorf("%w: unknown bump %q, use major, minor or patch", ErrInvalidVersion, bump)
+		}
+		return fmt.Sprintf("v%d.%d.%d", major, minor, patch), nil
+	}
+
+	return "", fmt.Errorf("unknown version scheme %q", scheme)
+}
@@ -0,0 +1,131 @@
+package version
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSortKeyOrder(t *testing.T) {
+	tests := []struct {
+		scheme string
+		labels []string
+	}{
+		{
+			scheme: SchemeSemver,
+			labels: []string{
+				"v0.0.0",
+				"v1.0.0-1",
+				"v1.0.0-2",
+				"v1.0.0-10",
+				"v1.0.0-alpha",
+				"v1.0.0-alpha.1",
+				"v1.0.0-alpha.beta",
+				"v1.0.0-beta",
+				"v1.0.0-beta.2",
+				"v1.0.0-beta.11",
+				"v1.0.0-rc.1",
+				"1.0.0",
+				"v1.0.1",
+				"v1.2.0",
+				"v1.10.0",
+				"v2.0.0",
+				"v10.0.0",
+			},
+		},
+		{
+			scheme: SchemeTimestamp,
+			labels: []string{"19700101000000", "20240101000000", "20240101000001", "20251231235959"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scheme, func(t *testing.T) {
+			previous := ""
+			for i, label := range tt.labels {
+				key, err := SortKey(tt.scheme, label)
+				if err != nil {
+					t.Fatalf("SortKey(%q) error: %v", label, err)
+				}
+				if i > 0 && key <= previous {
+					t.Errorf("SortKey(%q) = %q, want it after %q (%q)", label, key, tt.labels[i-1], previous)
+				}
+				previous = key
+			}
+
+			repeatable, err := RepeatableSortKey("refresh_views")
+			if err != nil {
+				t.Fatalf("RepeatableSortKey error: %v", err)
+			}
+			if repeatable <= previous {
+				t.Errorf("RepeatableSortKey = %q, want it after %q", repeatable, previous)
+			}
+		})
+	}
+}
+
+func TestSortKeyInvalid(t *testing.T) {
+	tests := []struct {
+		scheme string
+		label  string
+	}{
+		{SchemeSemver, "1.2"},
+		{SchemeSemver, "v1.2.3-"},
+		{SchemeSemver, "v1.2.3-alpha..1"},
+		{SchemeSemver, "v1000000.0.0"},
+		{SchemeSemver, "v1.0.0-1234567890"},
+		{SchemeTimestamp, "2024010100000"},
+		{SchemeTimestamp, "20241301000000"},
+	}
+
+	for _, tt := range tests {
+		if _, err := SortKey(tt.scheme, tt.label); !errors.Is(err, ErrInvalidVersion) {
+			t.Errorf("SortKey(%s, %q) error = %v, want ErrInvalidVersion", tt.scheme, tt.label, err)
+		}
+	}
+}
+
+func TestSortKeyEqualLabels(t *testing.T) {
+	a, _ := SortKey(SchemeSemver, "v1.0.0-01")
+	b, _ := SortKey(SchemeSemver, "1.0.0-1")
+	if a != b {
+		t.Errorf("SortKey of equal versions differ: %q and %q", a, b)
+	}
+}
+
+func TestNextVersion(t *testing.T) {
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		scheme  string
+		latest  string
+		bump    string
+		want    string
+		wantErr bool
+	}{
+		{name: "first semver", scheme: SchemeSemver, want: "v1.0.0"},
+		{name: "default bump", scheme: SchemeSemver, latest: "v1.2.3", want: "v1.2.4"},
+		{name: "minor", scheme: SchemeSemver, latest: "v1.2.3", bump: BumpMinor, want: "v1.3.0"},
+		{name: "major", scheme: SchemeSemver, latest: "1.2.3", bump: BumpMajor, want: "v2.0.0"},
+		{name: "release of a pre-release", scheme: SchemeSemver, latest: "v1.3.0-rc.1", bump: BumpPatch, want: "v1.3.0"},
+		{name: "unknown bump", scheme: SchemeSemver, latest: "v1.2.3", bump: "build", wantErr: true},
+		{name: "latest not semver", scheme: SchemeSemver, latest: "20240101000000", wantErr: true},
+		{name: "first timestamp", scheme: SchemeTimestamp, want: "20240506070809"},
+		{name: "timestamp after older", scheme: SchemeTimestamp, latest: "20240101000000", want: "20240506070809"},
+		{name: "timestamp after newer", scheme: SchemeTimestamp, latest: "20240506070809", want: "20240506070810"},
+		{name: "unknown scheme", scheme: "calver", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NextVersion(tt.scheme, tt.latest, tt.bump, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NextVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NextVersion() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -40,7 +40,9 @@ CREATE TABLE projects
     active          bool        DEFAULT true,
     visibility      VARCHAR(50) DEFAULT 'private'
         CONSTRAINT visibility_check CHECK (visibility IN ('private', 'public', 'internal')),
-    connection_type INT REFERENCES connection_types (id)
+    connection_type INT REFERENCES connection_types (id),
+    version_scheme  VARCHAR(16) DEFAULT 'semver' NOT NULL
+        CONSTRAINT version_scheme_check CHECK (version_scheme IN ('semver', 'timestamp'))
 );
 
 CREATE TABLE projects_credentials (
@@ -54,13 +56,37 @@ CREATE TABLE versions
 (
     id         SERIAL PRIMARY KEY,
     version    varchar(50) NOT NULL,
+    kind       varchar(16) NOT NULL DEFAULT 'versioned'
+        CONSTRAINT kind_check CHECK (kind IN ('versioned', 'repeatable')),
     up         jsonb,
     down       jsonb,
     state      varchar(128)
-        CONSTRAINT state_check CHECK (state IN ('pending', 'completed', 'failed')),
+        CONSTRAINT state_check CHECK (state IN ('pending', 'completed', 'failed', 'rolled_back', 'squashed')),
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     applied_at TIMESTAMP,
-    project_id INT REFERENCES projects (id)
+    project_id INT REFERENCES projects (id),
+    up_checksum      varchar(64),
+    down_checksum    varchar(64),
+    applied_checksum varchar(64),
+    -- Compared byte by byte like the keys built in Go.
+    sort_key         varchar(128) COLLATE "C" NOT NULL,
+    author_id        INT REFERENCES users (id),
+    description      TEXT,
+    squashed_into    INT REFERENCES versions (id)
+);
+
+-- Squashed versions keep their label, the version replacing them reuses it.
+CREATE UNIQUE INDEX versions_project_version_unique ON versions (project_id, version) WHERE state <> 'squashed';
+CREATE UNIQUE INDEX versions_project_sort_key_unique ON versions (project_id, sort_key) WHERE state <> 'squashed';
+
+CREATE TABLE migration_locks
+(
+    project_id  INT PRIMARY KEY REFERENCES projects (id) ON DELETE CASCADE,
+    holder_id   INT REFERENCES users (id),
+    operation   varchar(16) NOT NULL,
+    hostname    varchar(255),
+    backend_pid INT         NOT NULL,
+    acquired_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
 CREATE TABLE version_audit
@@ -72,6 +98,70 @@ CREATE TABLE version_audit
     notes      varchar(512)
 );
 
+CREATE TABLE lint_rules
+(
+    project_id INT REFERENCES projects (id) ON DELETE CASCADE,
+    rule       varchar(64) NOT NULL,
+    severity   varchar(16) NOT NULL
+        CONSTRAINT lint_severity_check CHECK (severity IN ('off', 'warning', 'error')),
+    PRIMARY KEY (project_id, rule)
+);
+
+CREATE TABLE version_lint
+(
+    id         SERIAL PRIMARY KEY,
+    version_id INT REFERENCES versions (id) ON DELETE CASCADE,
+    rule       varchar(64) NOT NULL,
+    kind       varchar(32) NOT NULL,
+    severity   varchar(16) NOT NULL
+        CONSTRAINT version_lint_severity_check CHECK (severity IN ('warning', 'error')),
+    statement  INT         NOT NULL,
+    message    TEXT        NOT NULL,
+    linted_at  TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX version_lint_version_idx ON version_lint (version_id);
+
+CREATE TABLE schema_snapshot_contents
+(
+    hash       varchar(64) PRIMARY KEY,
+    structure  jsonb NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE schema_snapshots
+(
+    id         SERIAL PRIMARY KEY,
+    project_id INT REFERENCES projects (id) ON DELETE CASCADE,
+    version_id INT REFERENCES versions (id),
+    hash       varchar(64) REFERENCES schema_snapshot_contents (hash),
+    source     varchar(32)
+        CONSTRAINT snapshot_source_check CHECK (source IN ('manual', 'version', 'schedule')),
+    created_by INT REFERENCES users (id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX schema_snapshots_project_idx ON schema_snapshots (project_id, id);
+
+CREATE TABLE drift_checks
+(
+    id          SERIAL PRIMARY KEY,
+    project_id  INT REFERENCES projects (id) ON DELETE CASCADE,
+    version_id  INT REFERENCES versions (id) ON DELETE SET NULL,
+    snapshot_id INT REFERENCES schema_snapshots (id) ON DELETE SET NULL,
+    status      varchar(16)
+        CONSTRAINT drift_status_check CHECK (status IN ('clean', 'drifted', 'unknown', 'error')),
+    message     TEXT,
+    diff        jsonb,
+    report      TEXT,
+    source      varchar(32)
+        CONSTRAINT drift_source_check CHECK (source IN ('manual', 'schedule')),
+    checked_by  INT REFERENCES users (id),
+    checked_at  TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX drift_checks_project_idx ON drift_checks (project_id, id);
+
 CREATE TABLE user_role
 (
     id         SERIAL PRIMARY KEY,
@@ -129,28 +219,28 @@ VALUES (1, 'Analytics Platform', 'A data analytics web application.', 'public',
        (4, 'DB Versioning Tool', 'A tool to manage database schema versions and migrations.', 'public', 1);
 
 -- ✅ versions
-INSERT INTO versions (version, up, down, state, applied_at, project_id)
-VALUES ('v1.0.0', '{
+INSERT INTO versions (version, sort_key, up, down, state, applied_at, project_id)
+VALUES ('v1.0.0', '000001.000000.000000~', '{
   "migrate": "create tables"
 }', '{
   "rollback": "drop tables"
 }', 'completed', NOW() - INTERVAL '10 days', 1),
-       ('v1.1.0', '{
+       ('v1.1.0', '000001.000001.000000~', '{
          "migrate": "add analytics views"
        }', '{
          "rollback": "drop views"
        }', 'completed', NOW() - INTERVAL '5 days', 1),
-       ('v1.0.0', '{
+       ('v1.0.0', '000001.000000.000000~', '{
          "migrate": "init ecommerce schema"
        }', '{
          "rollback": "drop ecommerce schema"
        }', 'completed', NOW() - INTERVAL '20 days', 2),
-       ('v1.0.1', '{
+       ('v1.0.1', '000001.000000.000001~', '{
          "migrate": "add payment table"
        }', '{
          "rollback": "drop payment table"
        }', 'pending', NULL, 2),
-       ('v0.9.0', '{
+       ('v0.9.0', '000000.000009.000000~', '{
          "migrate": "create sensors table"
        }', '{
          "rollback": "drop sensors"